@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+
+	"auto-wx-post/internal/config"
+)
+
+// defaultKeyName names the key given by -api-key or api.api_key in logs
+const defaultKeyName = "default"
+
+// apiKey is an accepted API key and the client name logged for it
+type apiKey struct {
+	name string
+	key  []byte
+}
+
+// loadAPIKeys collects the single key and the named keys from config,
+// skipping empty ones (e.g. an unset environment variable)
+func loadAPIKeys(single string, named []config.APIKeyConfig) []apiKey {
+	var keys []apiKey
+	if single != "" {
+		keys = append(keys, apiKey{name: defaultKeyName, key: []byte(single)})
+	}
+	for i, k := range named {
+		if k.Key == "" {
+			continue
+		}
+		name := k.Name
+		if name == "" {
+			name = fmt.Sprintf("key-%d", i+1)
+		}
+		keys = append(keys, apiKey{name: name, key: []byte(k.Key)})
+	}
+	return keys
+}
+
+// matchAPIKey returns the name of the key equal to token. Every key is
+// compared in constant time so the timing does not reveal which key or
+// which prefix matched.
+func matchAPIKey(keys []apiKey, token string) (string, bool) {
+	match := -1
+	for i, k := range keys {
+		if subtle.ConstantTimeCompare(k.key, []byte(token)) == 1 && match < 0 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return "", false
+	}
+	return keys[match].name, true
+}
+
+// requestInfo carries details filled in by inner handlers, such as the
+// authenticated client, back to the request log
+type requestInfo struct {
+	client string
+}
+
+type requestInfoKey struct{}
+
+// withRequestInfo attaches an empty requestInfo to the context
+func withRequestInfo(ctx context.Context) (context.Context, *requestInfo) {
+	info := &requestInfo{}
+	return context.WithValue(ctx, requestInfoKey{}, info), info
+}
+
+// requestInfoFrom returns the requestInfo attached by the logging middleware
+func requestInfoFrom(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*requestInfo)
+	return info
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"auto-wx-post/internal/wechat"
+)
+
+const (
+	// readinessTTL is how long a readiness result is reused, so frequent
+	// load balancer probes do not hit the WeChat token endpoint while it fails
+	readinessTTL = 15 * time.Second
+	// readinessTimeout bounds one WeChat connectivity check
+	readinessTimeout = 5 * time.Second
+)
+
+// ReadinessStatus is the body of /ready
+type ReadinessStatus struct {
+	Status    string `json:"status"`            // ready or unavailable
+	WeChat    string `json:"wechat"`            // reachable or error
+	ErrCode   int    `json:"errcode,omitempty"` // WeChat errcode, when WeChat answered with one
+	ErrMsg    string `json:"errmsg,omitempty"`  // WeChat errmsg, when WeChat answered with one
+	CheckedAt string `json:"checked_at"`
+}
+
+// readinessCache remembers the last WeChat connectivity check
+type readinessCache struct {
+	mutex   sync.Mutex
+	checked time.Time
+	err     error
+}
+
+// check returns the cached result while it is fresh and otherwise fetches
+// an access token (itself cached by the client). Concurrent probes wait for
+// the same check instead of starting their own.
+func (c *readinessCache) check(fetch func(ctx context.Context) error) (time.Time, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.checked.IsZero() && time.Since(c.checked) < readinessTTL {
+		return c.checked, c.err
+	}
+
+	// Not tied to the probe's request, so one aborted probe is not cached as a failure
+	ctx, cancel := context.WithTimeout(context.Background(), readinessTimeout)
+	defer cancel()
+
+	c.err = fetch(ctx)
+	c.checked = time.Now()
+	return c.checked, c.err
+}
+
+// handleReady reports whether the server can reach WeChat with its
+// credentials, returning 503 when it cannot
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	checkedAt, err := s.readiness.check(func(ctx context.Context) error {
+		_, err := s.wechatClient.GetAccessToken(ctx)
+		return err
+	})
+
+	status := ReadinessStatus{
+		Status:    "ready",
+		WeChat:    "reachable",
+		CheckedAt: checkedAt.Format(time.RFC3339),
+	}
+	if err != nil {
+		s.log.WarnContext(r.Context(), "Readiness check failed", "error", err)
+		status.Status = "unavailable"
+		status.WeChat = "error"
+		// Only WeChat's own errcode/errmsg is returned: this endpoint is
+		// unauthenticated and transport errors carry the token request URL
+		var apiErr *wechat.APIError
+		if errors.As(err, &apiErr) {
+			status.ErrCode = apiErr.Code
+			status.ErrMsg = apiErr.Msg
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(Response{
+			Success: false,
+			Data:    status,
+			Error:   "WeChat is unreachable",
+		})
+		return
+	}
+
+	s.respondSuccess(w, status)
+}
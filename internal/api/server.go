@@ -1,19 +1,23 @@
 package api
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
-	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"auto-wx-post/internal/cache"
+	"auto-wx-post/internal/catalog"
 	"auto-wx-post/internal/config"
+	"auto-wx-post/internal/dates"
 	"auto-wx-post/internal/logger"
 	"auto-wx-post/internal/markdown"
 	"auto-wx-post/internal/media"
+	"auto-wx-post/internal/metrics"
 	"auto-wx-post/internal/publisher"
 	"auto-wx-post/internal/wechat"
 )
@@ -25,9 +29,13 @@ type Server struct {
 	cacheManager *cache.Manager
 	mediaManager *media.Manager
 	publisher    *publisher.Publisher
+	resolve      publisher.Resolver // picks the publisher by the article's account, nil uses publisher
 	mdParser     *markdown.Parser
+	finder       *catalog.Finder
+	limiter      *rateLimiter // shared by routes that call the WeChat API, nil when disabled
+	readiness    readinessCache
 	log          *logger.Logger
-	apiKey       string // API authentication key
+	apiKeys      []apiKey // accepted API keys, auth is disabled when empty
 }
 
 // NewServer creates a new HTTP API server
@@ -40,18 +48,35 @@ func NewServer(
 	log *logger.Logger,
 	apiKey string,
 ) *Server {
+	mdParser := markdown.NewParser()
 	return &Server{
 		cfg:          cfg,
 		wechatClient: wechatClient,
 		cacheManager: cacheManager,
 		mediaManager: mediaManager,
 		publisher:    pub,
-		mdParser:     markdown.NewParser(),
+		mdParser:     mdParser,
+		finder:       catalog.NewFinder(cfg.Blog.SourcePath, mdParser, cacheManager, log.Logger),
+		limiter:      newRateLimiter(cfg.API.RateLimit, cfg.API.RateBurst),
 		log:          log,
-		apiKey:       apiKey,
+		apiKeys:      loadAPIKeys(apiKey, cfg.API.Keys),
 	}
 }
 
+// SetPublisherResolver routes publishes to the publisher of each article's
+// account (front matter account) instead of the one passed to NewServer
+func (s *Server) SetPublisherResolver(resolve publisher.Resolver) {
+	s.resolve = resolve
+}
+
+// publisherFor returns the publisher for the account filePath belongs to
+func (s *Server) publisherFor(filePath string) (*publisher.Publisher, error) {
+	if s.resolve == nil {
+		return s.publisher, nil
+	}
+	return s.resolve(filePath)
+}
+
 // Response represents a standard API response
 type Response struct {
 	Success bool        `json:"success"`
@@ -65,6 +90,9 @@ type ListArticlesRequest struct {
 	StartDate     string `json:"start_date,omitempty"`
 	EndDate       string `json:"end_date,omitempty"`
 	ShowPublished bool   `json:"show_published,omitempty"`
+	TagID         string `json:"tag_id,omitempty"`
+	Page          int    `json:"page,omitempty"`      // 1-based, defaults to 1
+	PageSize      int    `json:"page_size,omitempty"` // defaults to 50, max 500
 }
 
 // ParseArticleRequest represents the request for parsing an article
@@ -83,6 +111,45 @@ type PublishArticleRequest struct {
 	Force    bool   `json:"force,omitempty"`
 }
 
+// DeleteCacheRequest represents the request for deleting cache entries.
+// Exactly one of FilePath and Key is required.
+type DeleteCacheRequest struct {
+	FilePath string `json:"file_path,omitempty"` // forget every publish record of the file
+	Key      string `json:"key,omitempty"`       // delete a single entry by key
+}
+
+// PublishBatchRequest represents the request for publishing several articles
+type PublishBatchRequest struct {
+	FilePaths []string `json:"file_paths"`
+	Force     bool     `json:"force,omitempty"`
+	Group     bool     `json:"group,omitempty"` // submit as one multi-article draft (max 8)
+}
+
+// Batch item statuses
+const (
+	BatchSuccess = "success"
+	BatchSkipped = "skipped"
+	BatchError   = "error"
+)
+
+// BatchItemResult represents the outcome of publishing one article in a batch
+type BatchItemResult struct {
+	FilePath string                   `json:"file_path"`
+	Status   string                   `json:"status"` // success, skipped or error
+	Error    string                   `json:"error,omitempty"`
+	Result   *publisher.PublishResult `json:"result,omitempty"`
+}
+
+// PublishBatchResponse represents the response for a batch publish
+type PublishBatchResponse struct {
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Skipped   int               `json:"skipped"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+	MediaID   string            `json:"media_id,omitempty"` // group draft media_id when group=true
+}
+
 // ArticleInfo represents article information
 type ArticleInfo struct {
 	Path      string `json:"path"`
@@ -91,6 +158,7 @@ type ArticleInfo struct {
 	Date      string `json:"date"`
 	Subtitle  string `json:"subtitle"`
 	Published bool   `json:"published"`
+	TagID     string `json:"tag_id,omitempty"`
 }
 
 // ImageInfo represents uploaded image information
@@ -109,25 +177,47 @@ type CacheStatus struct {
 func (s *Server) SetupRoutes() http.Handler {
 	mux := http.NewServeMux()
 
-	// Health check
+	// Liveness and readiness checks
 	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/ready", s.handleReady)
+
+	// Prometheus metrics
+	mux.HandleFunc("/metrics", s.metricsAuthMiddleware(s.handleMetrics))
 
 	// API routes
 	mux.HandleFunc("/api/articles/list", s.authMiddleware(s.handleListArticles))
 	mux.HandleFunc("/api/articles/parse", s.authMiddleware(s.handleParseArticle))
-	mux.HandleFunc("/api/articles/publish", s.authMiddleware(s.handlePublishArticle))
-	mux.HandleFunc("/api/images/upload", s.authMiddleware(s.handleUploadImage))
+	mux.HandleFunc("/api/articles/publish", s.authMiddleware(s.rateLimitMiddleware(s.handlePublishArticle)))
+	mux.HandleFunc("/api/articles/publish-batch", s.authMiddleware(s.rateLimitMiddleware(s.handlePublishBatch)))
+	mux.HandleFunc("/api/images/upload", s.authMiddleware(s.rateLimitMiddleware(s.handleUploadImage)))
 	mux.HandleFunc("/api/cache/status", s.authMiddleware(s.handleCacheStatus))
+	mux.HandleFunc("/api/cache/entries", s.authMiddleware(s.handleCacheEntries))
+	mux.HandleFunc("/api/cache/delete", s.authMiddleware(s.handleDeleteCache))
 	mux.HandleFunc("/api/cache/clear", s.authMiddleware(s.handleClearCache))
 
 	return s.corsMiddleware(s.loggingMiddleware(mux))
 }
 
+// AuthEnabled reports whether requests must carry an API key
+func (s *Server) AuthEnabled() bool {
+	return len(s.apiKeys) > 0
+}
+
+// StartTLS serves the API on addr, over HTTPS when api.tls_cert and
+// api.tls_key are both set and plain HTTP otherwise
+func (s *Server) StartTLS(addr string) error {
+	handler := s.SetupRoutes()
+	if s.cfg.API.TLSCert != "" && s.cfg.API.TLSKey != "" {
+		return http.ListenAndServeTLS(addr, s.cfg.API.TLSCert, s.cfg.API.TLSKey, handler)
+	}
+	return http.ListenAndServe(addr, handler)
+}
+
 // authMiddleware checks API key authentication
 func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Skip auth if no API key is configured
-		if s.apiKey == "" {
+		if len(s.apiKeys) == 0 {
 			next(w, r)
 			return
 		}
@@ -140,15 +230,60 @@ func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 		// Support both "Bearer <token>" and "<token>" formats
 		token := strings.TrimPrefix(authHeader, "Bearer ")
-		if token != s.apiKey {
+		name, ok := matchAPIKey(s.apiKeys, token)
+		if !ok {
 			s.respondError(w, http.StatusUnauthorized, "Invalid API key")
 			return
 		}
+		if info := requestInfoFrom(r.Context()); info != nil {
+			info.client = name
+		}
 
 		next(w, r)
 	}
 }
 
+// rateLimitMiddleware rejects requests with 429 once the shared WeChat quota
+// bucket is empty, telling the client when to retry
+func (s *Server) rateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.limiter == nil {
+			next(w, r)
+			return
+		}
+
+		if ok, wait := s.limiter.reserve(); !ok {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			s.respondError(w, http.StatusTooManyRequests,
+				fmt.Sprintf("Rate limit exceeded, retry after %d seconds", retryAfter))
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// metricsAuthMiddleware checks api.metrics_token when configured, so a
+// scraper does not need a full API key. Otherwise the API keys apply.
+func (s *Server) metricsAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	if s.cfg.API.MetricsToken == "" {
+		return s.authMiddleware(next)
+	}
+	token := []byte(s.cfg.API.MetricsToken)
+	return func(w http.ResponseWriter, r *http.Request) {
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), token) != 1 {
+			s.respondError(w, http.StatusUnauthorized, "Invalid metrics token")
+			return
+		}
+		if info := requestInfoFrom(r.Context()); info != nil {
+			info.client = "metrics"
+		}
+		next(w, r)
+	}
+}
+
 // corsMiddleware adds CORS headers
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -169,21 +304,58 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		s.log.Info("HTTP request",
+
+		// Reuse the caller's X-Request-ID so logs can be joined across services
+		requestID := r.Header.Get("X-Request-ID")
+		if !validRequestID(requestID) {
+			requestID = logger.NewRequestID()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+
+		ctx, info := withRequestInfo(logger.WithRequestID(r.Context(), requestID))
+		s.log.InfoContext(ctx, "HTTP request",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"remote", r.RemoteAddr)
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(ctx))
 
-		s.log.Info("HTTP response",
+		s.log.InfoContext(ctx, "HTTP response",
 			"method", r.Method,
 			"path", r.URL.Path,
+			"client", info.client,
 			"duration", time.Since(start))
 	})
 }
 
-// handleHealth handles health check requests
+// validRequestID accepts short printable ids, so a client cannot inject
+// arbitrary text into the logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// handleMetrics exposes metrics in the Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := metrics.WriteText(w); err != nil {
+		s.log.WarnContext(r.Context(), "Failed to write metrics", "error", err)
+	}
+}
+
+// handleHealth handles liveness checks; it never calls WeChat, see handleReady
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
@@ -210,15 +382,48 @@ func (s *Server) handleListArticles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	articles, err := s.findArticles(req.StartDate, req.EndDate, req.ShowPublished)
+	now := time.Now()
+	startDate, err := dates.ResolveOptional(req.StartDate, now)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid start_date: %v", err))
+		return
+	}
+	endDate, err := dates.ResolveOptional(req.EndDate, now)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid end_date: %v", err))
+		return
+	}
+
+	page, err := s.finder.FindPage(catalog.Filter{
+		StartDate:     startDate,
+		EndDate:       endDate,
+		ShowPublished: req.ShowPublished,
+		TagID:         req.TagID,
+	}, req.Page, req.PageSize)
 	if err != nil {
 		s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find articles: %v", err))
 		return
 	}
 
+	articles := make([]ArticleInfo, 0, len(page.Articles))
+	for _, article := range page.Articles {
+		articles = append(articles, ArticleInfo{
+			Path:      article.Path,
+			Title:     article.Title,
+			Author:    article.Author,
+			Date:      article.Date,
+			Subtitle:  article.Subtitle,
+			Published: article.Published,
+			TagID:     article.TagID,
+		})
+	}
+
 	s.respondSuccess(w, map[string]interface{}{
-		"count":    len(articles),
-		"articles": articles,
+		"count":     len(articles),
+		"total":     page.Total,
+		"page":      page.Page,
+		"page_size": page.PageSize,
+		"articles":  articles,
 	})
 }
 
@@ -279,7 +484,7 @@ func (s *Server) handleUploadImage(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	imageInfo, err := s.mediaManager.UploadImage(ctx, req.ImagePath)
 	if err != nil {
-		s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to upload image: %v", err))
+		s.respondWeChatError(w, err, "Failed to upload image")
 		return
 	}
 
@@ -307,26 +512,194 @@ func (s *Server) handlePublishArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pub, err := s.publisherFor(req.FilePath)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Check if already published
-	if !req.Force {
-		published, _ := s.cacheManager.IsFileProcessed(req.FilePath)
-		if published {
-			s.respondError(w, http.StatusConflict, "Article already published. Use force=true to republish.")
-			return
-		}
+	publish := pub.PublishArticle
+	if req.Force {
+		publish = pub.RepublishArticle
+	} else if published, _ := pub.IsPublished(req.FilePath); published {
+		s.respondError(w, http.StatusConflict, "Article already published. Use force=true to republish.")
+		return
 	}
 
 	ctx := r.Context()
-	err := s.publisher.PublishArticle(ctx, req.FilePath)
+	result, err := publish(ctx, req.FilePath)
 	if err != nil {
-		s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to publish article: %v", err))
+		s.respondWeChatError(w, err, "Failed to publish article")
 		return
 	}
 
-	s.respondSuccess(w, map[string]interface{}{
-		"file_path": req.FilePath,
-		"message":   "Article published successfully",
-	})
+	s.respondSuccess(w, result)
+}
+
+// handlePublishBatch publishes several articles in order, pacing requests like
+// the CLI batch run. Individual failures are reported per file and do not stop
+// the remaining articles.
+func (s *Server) handlePublishBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req PublishBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+
+	if len(req.FilePaths) == 0 {
+		s.respondError(w, http.StatusBadRequest, "file_paths is required")
+		return
+	}
+
+	if req.Group {
+		s.publishGroup(w, r, req)
+		return
+	}
+
+	ctx := r.Context()
+	pacer := publisher.NewPacer(
+		time.Duration(s.cfg.Publish.Interval)*time.Second,
+		time.Duration(s.cfg.Publish.RateLimitBackoff)*time.Second)
+
+	resp := PublishBatchResponse{
+		Total:   len(req.FilePaths),
+		Results: make([]BatchItemResult, 0, len(req.FilePaths)),
+	}
+	var lastErr error
+	published := 0
+	for _, filePath := range req.FilePaths {
+		item := BatchItemResult{FilePath: filePath}
+
+		pub, err := s.publisherFor(filePath)
+		if err != nil {
+			item.Status = BatchError
+			item.Error = err.Error()
+			resp.Failed++
+			resp.Results = append(resp.Results, item)
+			continue
+		}
+
+		publish := pub.PublishArticle
+		if req.Force {
+			publish = pub.RepublishArticle
+		} else if done, _ := pub.IsPublished(filePath); done {
+			item.Status = BatchSkipped
+			item.Error = "already published"
+			resp.Skipped++
+			resp.Results = append(resp.Results, item)
+			continue
+		}
+
+		// Space out requests to the WeChat API, backing off after rate limits
+		if published > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(pacer.Next(lastErr)):
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			item.Status = BatchError
+			item.Error = fmt.Sprintf("request cancelled: %v", err)
+			resp.Failed++
+			resp.Results = append(resp.Results, item)
+			continue
+		}
+
+		result, err := publish(ctx, filePath)
+		published++
+		lastErr = err
+		if err != nil {
+			s.log.WarnContext(r.Context(), "Batch publish failed", "file", filePath, "error", err)
+			item.Status = BatchError
+			item.Error = err.Error()
+			resp.Failed++
+		} else {
+			item.Status = BatchSuccess
+			item.Result = result
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, item)
+	}
+
+	s.respondSuccess(w, resp)
+}
+
+// publishGroup submits the unpublished articles of a batch as a single
+// multi-article draft. The group is all-or-nothing, so a failure marks every
+// submitted article as an error.
+func (s *Server) publishGroup(w http.ResponseWriter, r *http.Request, req PublishBatchRequest) {
+	if len(req.FilePaths) > wechat.MaxGroupArticles {
+		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("group accepts at most %d articles", wechat.MaxGroupArticles))
+		return
+	}
+
+	resp := PublishBatchResponse{
+		Total:   len(req.FilePaths),
+		Results: make([]BatchItemResult, 0, len(req.FilePaths)),
+	}
+	// A multi-article draft belongs to one account
+	var pub *publisher.Publisher
+	for _, filePath := range req.FilePaths {
+		p, err := s.publisherFor(filePath)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if pub != nil && p != pub {
+			s.respondError(w, http.StatusBadRequest, "group articles must belong to the same account")
+			return
+		}
+		pub = p
+	}
+
+	var pending []string
+	for _, filePath := range req.FilePaths {
+		if !req.Force {
+			if done, _ := pub.IsPublished(filePath); done {
+				resp.Skipped++
+				resp.Results = append(resp.Results, BatchItemResult{
+					FilePath: filePath,
+					Status:   BatchSkipped,
+					Error:    "already published",
+				})
+				continue
+			}
+		}
+		pending = append(pending, filePath)
+	}
+
+	if len(pending) > 0 {
+		group, err := pub.PublishArticleGroup(r.Context(), pending)
+		if err != nil {
+			s.log.WarnContext(r.Context(), "Group publish failed", "count", len(pending), "error", err)
+			for _, filePath := range pending {
+				resp.Failed++
+				resp.Results = append(resp.Results, BatchItemResult{
+					FilePath: filePath,
+					Status:   BatchError,
+					Error:    err.Error(),
+				})
+			}
+		} else {
+			resp.MediaID = group.MediaID
+			for _, result := range group.Articles {
+				resp.Succeeded++
+				resp.Results = append(resp.Results, BatchItemResult{
+					FilePath: result.FilePath,
+					Status:   BatchSuccess,
+					Result:   result,
+				})
+			}
+		}
+	}
+
+	s.respondSuccess(w, resp)
 }
 
 // handleCacheStatus handles getting cache status
@@ -343,6 +716,74 @@ func (s *Server) handleCacheStatus(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleCacheEntries lists decoded cache entries, optionally filtered by the
+// kind query parameter (file, publish or image)
+func (s *Server) handleCacheEntries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	kind := r.URL.Query().Get("kind")
+	entries := make([]cache.Entry, 0)
+	for _, entry := range s.cacheManager.Entries() {
+		if kind == "" || entry.Kind == kind {
+			entries = append(entries, entry)
+		}
+	}
+
+	s.respondSuccess(w, map[string]interface{}{
+		"count":   len(entries),
+		"entries": entries,
+	})
+}
+
+// handleDeleteCache deletes the cache entries of one article (so it is
+// published again on the next run) or a single entry by key
+func (s *Server) handleDeleteCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req DeleteCacheRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if (req.FilePath == "") == (req.Key == "") {
+		s.respondError(w, http.StatusBadRequest, "exactly one of file_path and key is required")
+		return
+	}
+
+	var removed int
+	if req.FilePath != "" {
+		n, err := s.cacheManager.DeleteByFilePath(req.FilePath)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete cache entries: %v", err))
+			return
+		}
+		removed = n
+	} else {
+		existed, err := s.cacheManager.Delete(req.Key)
+		if err != nil {
+			s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete cache entry: %v", err))
+			return
+		}
+		if existed {
+			removed = 1
+		}
+	}
+
+	if removed == 0 {
+		s.respondError(w, http.StatusNotFound, "No matching cache entry")
+		return
+	}
+	s.respondSuccess(w, map[string]interface{}{
+		"removed": removed,
+	})
+}
+
 // handleClearCache handles clearing cache
 func (s *Server) handleClearCache(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -380,58 +821,16 @@ func (s *Server) respondError(w http.ResponseWriter, statusCode int, message str
 	})
 }
 
-func (s *Server) findArticles(startDate, endDate string, showPublished bool) ([]ArticleInfo, error) {
-	var articles []ArticleInfo
-
-	sourcePath := s.cfg.Blog.SourcePath
-	err := filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() || filepath.Ext(path) != ".md" {
-			return nil
-		}
-
-		// Parse article to get metadata
-		article, err := s.mdParser.ParseFile(path)
-		if err != nil {
-			s.log.Warn("Failed to parse article", "path", path, "error", err)
-			return nil
-		}
-
-		// Check date range if specified
-		if startDate != "" && article.Date < startDate {
-			return nil
-		}
-		if endDate != "" && article.Date > endDate {
-			return nil
-		}
-
-		// Check published status
-		published, _ := s.cacheManager.IsFileProcessed(path)
-		if !showPublished && published {
-			return nil
-		}
-
-		title := article.Title
-		if title == "" {
-			title = filepath.Base(path)
-		}
-
-		articles = append(articles, ArticleInfo{
-			Path:      path,
-			Title:     title,
-			Author:    article.Author,
-			Date:      article.Date,
-			Subtitle:  article.Subtitle,
-			Published: published,
-		})
-
-		return nil
-	})
-
-	return articles, err
+// respondWeChatError reports a failed WeChat operation. Rate limiting by
+// WeChat is returned as 429 with the configured backoff as Retry-After, so
+// clients can tell it apart from permanent failures.
+func (s *Server) respondWeChatError(w http.ResponseWriter, err error, message string) {
+	if wechat.IsRateLimited(err) {
+		w.Header().Set("Retry-After", strconv.Itoa(s.cfg.Publish.RateLimitBackoff))
+		s.respondError(w, http.StatusTooManyRequests, fmt.Sprintf("%s: %v", message, err))
+		return
+	}
+	s.respondError(w, http.StatusInternalServerError, fmt.Sprintf("%s: %v", message, err))
 }
 
 func truncateString(s string, maxLen int) string {
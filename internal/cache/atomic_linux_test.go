@@ -0,0 +1,64 @@
+//go:build linux
+
+package cache
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// TestWriteFileAtomicPartialWrite 通过 RLIMIT_FSIZE 让写入只完成一部分后失败 (EFBIG)，
+// 确认返回错误、原文件保持完整且不留下临时文件
+func TestWriteFileAtomicPartialWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache.json")
+	good := []byte(`[{"key":"a","value":"1"}]`)
+	if err := WriteFileAtomic(path, good); err != nil {
+		t.Fatalf("write good file: %v", err)
+	}
+
+	// 超过文件大小限制时内核默认发送 SIGXFSZ 终止进程，忽略后写入返回 EFBIG
+	signal.Ignore(syscall.SIGXFSZ)
+	defer signal.Reset(syscall.SIGXFSZ)
+
+	var old syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_FSIZE, &old); err != nil {
+		t.Skipf("getrlimit: %v", err)
+	}
+	limit := old
+	limit.Cur = 1024
+	if err := syscall.Setrlimit(syscall.RLIMIT_FSIZE, &limit); err != nil {
+		t.Skipf("setrlimit: %v", err)
+	}
+	err := WriteFileAtomic(path, bytes.Repeat([]byte("x"), 4096))
+	if restoreErr := syscall.Setrlimit(syscall.RLIMIT_FSIZE, &old); restoreErr != nil {
+		t.Fatalf("restore rlimit: %v", restoreErr)
+	}
+
+	if err == nil {
+		t.Fatal("WriteFileAtomic succeeded past the file size limit, want error")
+	}
+
+	got, readErr := os.ReadFile(path)
+	if readErr != nil {
+		t.Fatalf("read file: %v", readErr)
+	}
+	if !bytes.Equal(got, good) {
+		t.Errorf("file = %q after failed write, want previous content %q", got, good)
+	}
+
+	entries, readErr := os.ReadDir(dir)
+	if readErr != nil {
+		t.Fatalf("read dir: %v", readErr)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("temp file %s left behind", entry.Name())
+		}
+	}
+}
@@ -6,15 +6,31 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
+// KeyStrategy 已处理文件的缓存键策略
+type KeyStrategy string
+
+const (
+	// KeyStrategyContent 按文件内容MD5 (任何修改都会触发重新发布)
+	KeyStrategyContent KeyStrategy = "content"
+	// KeyStrategyPath 按文件路径 (修改内容不会触发重新发布)
+	KeyStrategyPath KeyStrategy = "path"
+	// KeyStrategyPathMtime 按文件路径+修改时间 (无需读取内容，但touch也会触发重新发布)
+	KeyStrategyPathMtime KeyStrategy = "path+mtime"
+)
+
 // Manager 缓存管理器 (线程安全)
 type Manager struct {
-	store     map[string]*CacheEntry
-	storePath string
-	mutex     sync.RWMutex
+	store       map[string]*CacheEntry
+	storePath   string
+	keyStrategy KeyStrategy
+	mutex       sync.RWMutex
 }
 
 // CacheEntry 缓存条目
@@ -24,11 +40,47 @@ type CacheEntry struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// FileRecord 已处理文件的记录
+type FileRecord struct {
+	FilePath       string    `json:"file_path"`
+	MediaID        string    `json:"media_id,omitempty"`
+	HTMLHash       string    `json:"html_hash,omitempty"`        // 草稿数据摘要 (不含随机生成的封面)，未变化时跳过发布
+	ContentHash    string    `json:"content_hash,omitempty"`     // 源文件MD5，用于判断是否只需重新排版
+	SeriesMediaIDs []string  `json:"series_media_ids,omitempty"` // 超长文章拆分后各部分的草稿
+	ThumbMediaID   string    `json:"thumb_media_id,omitempty"`   // 草稿封面，随机封面的文章重新渲染时沿用
+	TagID          string    `json:"tag_id,omitempty"`           // front matter 中的分组标签
+	GroupIndex     int       `json:"group_index,omitempty"`      // 在多图文草稿中的位置 (0 为头条)
+	PublishedAt    time.Time `json:"published_at"`
+}
+
+// DefaultAccount 未指定公众号账号时使用的名称
+const DefaultAccount = "default"
+
+// StorePath 展开缓存路径中的 {account} 占位符，使每个公众号账号拥有独立的缓存
+// (素材 media_id 不能跨账号使用)
+func StorePath(template, account string) string {
+	if account == "" {
+		account = DefaultAccount
+	}
+	return strings.ReplaceAll(template, "{account}", account)
+}
+
 // NewManager 创建缓存管理器
-func NewManager(storePath string) (*Manager, error) {
+func NewManager(storePath string, keyStrategy KeyStrategy) (*Manager, error) {
+	if keyStrategy == "" {
+		keyStrategy = KeyStrategyContent
+	}
+
+	if dir := filepath.Dir(storePath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create cache dir: %w", err)
+		}
+	}
+
 	m := &Manager{
-		store:     make(map[string]*CacheEntry),
-		storePath: storePath,
+		store:       make(map[string]*CacheEntry),
+		storePath:   storePath,
+		keyStrategy: keyStrategy,
 	}
 
 	// 尝试加载现有缓存
@@ -51,6 +103,21 @@ func (m *Manager) Get(key string) (string, bool) {
 	return entry.Value, true
 }
 
+// GetWithTTL 获取缓存，写入时间早于 ttl 的条目视为不存在 (ttl<=0 时不过期)
+func (m *Manager) GetWithTTL(key string, ttl time.Duration) (string, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	entry, exists := m.store[key]
+	if !exists {
+		return "", false
+	}
+	if ttl > 0 && time.Since(entry.Timestamp) > ttl {
+		return "", false
+	}
+	return entry.Value, true
+}
+
 // Set 设置缓存
 func (m *Manager) Set(key, value string) error {
 	m.mutex.Lock()
@@ -81,26 +148,138 @@ func FileDigest(filePath string) (string, error) {
 	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
 
+// FileKey 按配置的策略计算已处理文件的缓存键
+func (m *Manager) FileKey(filePath string) (string, error) {
+	switch m.keyStrategy {
+	case KeyStrategyPath:
+		absPath, err := filepath.Abs(filePath)
+		if err != nil {
+			return "", fmt.Errorf("resolve path: %w", err)
+		}
+		return fmt.Sprintf("path_%x", md5.Sum([]byte(absPath))), nil
+	case KeyStrategyPathMtime:
+		absPath, err := filepath.Abs(filePath)
+		if err != nil {
+			return "", fmt.Errorf("resolve path: %w", err)
+		}
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return "", fmt.Errorf("stat file: %w", err)
+		}
+		key := fmt.Sprintf("%s|%d", absPath, info.ModTime().UnixNano())
+		return fmt.Sprintf("mtime_%x", md5.Sum([]byte(key))), nil
+	default:
+		return FileDigest(filePath)
+	}
+}
+
 // IsFileProcessed 检查文件是否已处理
 func (m *Manager) IsFileProcessed(filePath string) (bool, error) {
-	digest, err := FileDigest(filePath)
+	key, err := m.FileKey(filePath)
 	if err != nil {
 		return false, err
 	}
 
-	_, exists := m.Get(digest)
+	_, exists := m.Get(key)
 	return exists, nil
 }
 
-// MarkFileProcessed 标记文件为已处理
-func (m *Manager) MarkFileProcessed(filePath string) error {
-	digest, err := FileDigest(filePath)
+// MarkFileProcessed 标记文件为已处理，同时按路径记录最近一次发布
+func (m *Manager) MarkFileProcessed(filePath string, record FileRecord) error {
+	key, err := m.FileKey(filePath)
 	if err != nil {
 		return err
 	}
 
-	value := fmt.Sprintf("%s:%s", filePath, time.Now().Format(time.RFC3339))
-	return m.Set(digest, value)
+	record.FilePath = filePath
+	record.PublishedAt = time.Now()
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal file record: %w", err)
+	}
+
+	if err := m.Set(key, string(data)); err != nil {
+		return err
+	}
+
+	publishKey, err := publishRecordKey(filePath)
+	if err != nil {
+		return err
+	}
+	return m.Set(publishKey, string(data))
+}
+
+// GetPublishRecord 按文件路径获取最近一次发布记录 (不受内容变化影响)
+func (m *Manager) GetPublishRecord(filePath string) (*FileRecord, bool) {
+	key, err := publishRecordKey(filePath)
+	if err != nil {
+		return nil, false
+	}
+
+	value, exists := m.Get(key)
+	if !exists {
+		return nil, false
+	}
+	return parseFileRecord(value), true
+}
+
+// PublishRecords 返回所有按路径记录的发布记录，按文件路径排序
+func (m *Manager) PublishRecords() []*FileRecord {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var records []*FileRecord
+	for key, entry := range m.store {
+		if strings.HasPrefix(key, "pub_") {
+			records = append(records, parseFileRecord(entry.Value))
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].FilePath < records[j].FilePath
+	})
+	return records
+}
+
+// publishRecordKey 计算按路径索引的发布记录键
+func publishRecordKey(filePath string) (string, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", fmt.Errorf("resolve path: %w", err)
+	}
+	return fmt.Sprintf("pub_%x", md5.Sum([]byte(absPath))), nil
+}
+
+// GetFileRecord 获取已处理文件的记录
+func (m *Manager) GetFileRecord(filePath string) (*FileRecord, bool) {
+	key, err := m.FileKey(filePath)
+	if err != nil {
+		return nil, false
+	}
+
+	value, exists := m.Get(key)
+	if !exists {
+		return nil, false
+	}
+	return parseFileRecord(value), true
+}
+
+// parseFileRecord 解析文件记录 (兼容旧的 "路径:时间" 格式)
+func parseFileRecord(value string) *FileRecord {
+	var record FileRecord
+	if err := json.Unmarshal([]byte(value), &record); err == nil {
+		return &record
+	}
+
+	// 旧格式: <path>:<RFC3339>，时间本身包含冒号，从右侧匹配
+	for i := len(value) - 1; i >= 0; i-- {
+		if value[i] != ':' {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, value[i+1:]); err == nil {
+			return &FileRecord{FilePath: value[:i], PublishedAt: t}
+		}
+	}
+	return &FileRecord{FilePath: value}
 }
 
 // load 从文件加载缓存
@@ -134,13 +313,146 @@ func (m *Manager) save() error {
 		return fmt.Errorf("marshal cache: %w", err)
 	}
 
-	if err := os.WriteFile(m.storePath, data, 0644); err != nil {
+	return WriteFileAtomic(m.storePath, data)
+}
+
+// WriteFileAtomic 先写入同目录下的临时文件并同步到磁盘，再重命名覆盖目标文件，
+// 进程中途被终止时原文件保持完整
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp cache file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
 		return fmt.Errorf("write cache file: %w", err)
 	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("sync cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("close cache file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("chmod cache file: %w", err)
+	}
 
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("replace cache file: %w", err)
+	}
 	return nil
 }
 
+// 缓存条目类型
+const (
+	EntryFile    = "file"    // 已处理文件 (按缓存键策略)
+	EntryPublish = "publish" // 按路径索引的最近一次发布记录
+	EntryImage   = "image"   // 图片上传结果
+)
+
+// Entry 解码后的缓存条目
+type Entry struct {
+	Key       string    `json:"key"`
+	Kind      string    `json:"kind"`
+	FilePath  string    `json:"file_path,omitempty"`
+	MediaID   string    `json:"media_id,omitempty"`
+	URL       string    `json:"url,omitempty"` // 图片地址
+	Timestamp time.Time `json:"timestamp"`     // 发布时间，没有时为写入缓存的时间
+}
+
+// Entries 返回全部缓存条目，文件记录解码出路径和发布时间，按时间倒序排列
+func (m *Manager) Entries() []Entry {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	entries := make([]Entry, 0, len(m.store))
+	for key, stored := range m.store {
+		entry := Entry{Key: key, Timestamp: stored.Timestamp}
+		switch {
+		case strings.HasPrefix(key, "img"):
+			entry.Kind = EntryImage
+			entry.MediaID, entry.URL, _ = strings.Cut(stored.Value, "|")
+		default:
+			entry.Kind = EntryFile
+			if strings.HasPrefix(key, "pub_") {
+				entry.Kind = EntryPublish
+			}
+			record := parseFileRecord(stored.Value)
+			entry.FilePath = record.FilePath
+			entry.MediaID = record.MediaID
+			if !record.PublishedAt.IsZero() {
+				entry.Timestamp = record.PublishedAt
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Timestamp.Equal(entries[j].Timestamp) {
+			return entries[i].Timestamp.After(entries[j].Timestamp)
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// Delete 删除指定键的缓存条目，返回条目是否存在
+func (m *Manager) Delete(key string) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.store[key]; !exists {
+		return false, nil
+	}
+	delete(m.store, key)
+	return true, m.save()
+}
+
+// DeleteByFilePath 删除文件的全部发布记录：当前缓存键、按路径索引的记录，
+// 以及内容修改前按旧内容哈希记录的条目。返回删除的条目数
+func (m *Manager) DeleteByFilePath(filePath string) (int, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("resolve path: %w", err)
+	}
+	publishKey, err := publishRecordKey(filePath)
+	if err != nil {
+		return 0, err
+	}
+	// 文件已被删除时无法计算当前缓存键，只按记录中的路径匹配
+	fileKey, _ := m.FileKey(filePath)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	removed := 0
+	for key, entry := range m.store {
+		match := key == publishKey || key == fileKey
+		if !match && !strings.HasPrefix(key, "img") {
+			if recorded := parseFileRecord(entry.Value).FilePath; recorded != "" {
+				recordedAbs, err := filepath.Abs(recorded)
+				match = err == nil && recordedAbs == absPath
+			}
+		}
+		if match {
+			delete(m.store, key)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, m.save()
+}
+
 // Clear 清空缓存
 func (m *Manager) Clear() error {
 	m.mutex.Lock()
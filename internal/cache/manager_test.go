@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWriteFileAtomicInterrupted 模拟写入中途进程被终止：临时文件只写了一半、未重命名，
+// 目标文件应保持上一次的完整内容，缓存仍可正常加载
+func TestWriteFileAtomicInterrupted(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "cache.json")
+
+	m, err := NewManager(storePath, KeyStrategyContent)
+	if err != nil {
+		t.Fatalf("create cache: %v", err)
+	}
+	if err := m.Set("image1", "media1|https://mmbiz.qpic.cn/1"); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	good, err := os.ReadFile(storePath)
+	if err != nil {
+		t.Fatalf("read cache file: %v", err)
+	}
+
+	// 被终止的写入留下的半截临时文件
+	tmp, err := os.CreateTemp(filepath.Dir(storePath), filepath.Base(storePath)+".tmp-*")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	if _, err := tmp.Write(good[:len(good)/2]); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	tmp.Close()
+
+	got, err := os.ReadFile(storePath)
+	if err != nil {
+		t.Fatalf("read cache file: %v", err)
+	}
+	if string(got) != string(good) {
+		t.Errorf("cache file changed after interrupted write:\n%s", got)
+	}
+
+	reloaded, err := NewManager(storePath, KeyStrategyContent)
+	if err != nil {
+		t.Fatalf("reload cache: %v", err)
+	}
+	if value, ok := reloaded.Get("image1"); !ok || value != "media1|https://mmbiz.qpic.cn/1" {
+		t.Errorf("Get(image1) = %q, %v after reload", value, ok)
+	}
+}
@@ -0,0 +1,165 @@
+package catalog
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"auto-wx-post/internal/cache"
+	"auto-wx-post/internal/dates"
+	"auto-wx-post/internal/markdown"
+)
+
+const (
+	// DefaultPageSize 未指定每页数量时的默认值
+	DefaultPageSize = 50
+	// MaxPageSize 每页数量上限
+	MaxPageSize = 500
+)
+
+// Filter 文章列表的过滤条件，零值表示不限制
+type Filter struct {
+	StartDate     time.Time // 按天比较，含当天
+	EndDate       time.Time // 按天比较，含当天
+	ShowPublished bool      // 是否包含已发布的文章
+	TagID         string    // 只列出 front matter tag_id 匹配的文章
+}
+
+// Summary 文章列表中的单篇文章
+type Summary struct {
+	Path      string
+	Title     string
+	Author    string
+	Date      string
+	DateTime  time.Time
+	Subtitle  string
+	Published bool
+	TagID     string
+	Images    int // 正文图片数
+	Length    int // 正文字数
+}
+
+// Page 分页后的文章列表
+type Page struct {
+	Articles []Summary
+	Total    int // 过滤后的文章总数
+	Page     int // 当前页码，从 1 开始
+	PageSize int
+}
+
+// Finder 遍历文章目录并按条件过滤，供 HTTP API 和 MCP 共用
+type Finder struct {
+	sourcePath   string
+	mdParser     *markdown.Parser
+	cacheManager *cache.Manager
+	log          *slog.Logger
+}
+
+// NewFinder 创建文章查找器
+func NewFinder(sourcePath string, mdParser *markdown.Parser, cacheManager *cache.Manager, log *slog.Logger) *Finder {
+	return &Finder{
+		sourcePath:   sourcePath,
+		mdParser:     mdParser,
+		cacheManager: cacheManager,
+		log:          log,
+	}
+}
+
+// Find 返回符合条件的全部文章，按日期从新到旧排序 (日期相同或缺失时按路径)，保证分页结果稳定。
+// 指定日期范围时，没有日期或日期无法识别的文章不会返回
+func (f *Finder) Find(filter Filter) ([]Summary, error) {
+	var result []Summary
+
+	err := filepath.Walk(f.sourcePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".md" {
+			return nil
+		}
+
+		article, err := f.mdParser.ParseFile(path)
+		if err != nil {
+			f.log.Warn("Failed to parse article", "path", path, "error", err)
+			return nil
+		}
+
+		if !filter.StartDate.IsZero() || !filter.EndDate.IsZero() {
+			if article.DateTime.IsZero() || !dates.InDayRange(article.DateTime, filter.StartDate, filter.EndDate) {
+				return nil
+			}
+		}
+		if filter.TagID != "" && article.TagID != filter.TagID {
+			return nil
+		}
+
+		published, _ := f.cacheManager.IsFileProcessed(path)
+		if published && !filter.ShowPublished {
+			return nil
+		}
+
+		title := article.Title
+		if title == "" {
+			title = filepath.Base(path)
+		}
+
+		result = append(result, Summary{
+			Path:      path,
+			Title:     title,
+			Author:    article.Author,
+			Date:      article.Date,
+			DateTime:  article.DateTime,
+			Subtitle:  article.Subtitle,
+			Published: published,
+			TagID:     article.TagID,
+			Images:    len(article.Images),
+			Length:    utf8.RuneCountInString(article.Content),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if !a.DateTime.Equal(b.DateTime) {
+			return a.DateTime.After(b.DateTime)
+		}
+		return a.Path < b.Path
+	})
+	return result, nil
+}
+
+// FindPage 返回符合条件的指定页。page 小于 1 时为第一页，pageSize 未指定时使用默认值，超过上限时截断
+func (f *Finder) FindPage(filter Filter, page, pageSize int) (*Page, error) {
+	all, err := f.Find(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	start := len(all)
+	if page-1 <= len(all)/pageSize {
+		start = min((page-1)*pageSize, len(all))
+	}
+	end := min(start+pageSize, len(all))
+	return &Page{
+		Articles: all[start:end],
+		Total:    len(all),
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
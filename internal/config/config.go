@@ -10,20 +10,50 @@ import (
 
 // Config 全局配置结构
 type Config struct {
-	WeChat  WeChatConfig  `yaml:"wechat"`
-	Blog    BlogConfig    `yaml:"blog"`
-	Cache   CacheConfig   `yaml:"cache"`
-	Image   ImageConfig   `yaml:"image"`
-	Publish PublishConfig `yaml:"publish"`
-	Log     LogConfig     `yaml:"log"`
+	WeChat   WeChatConfig   `yaml:"wechat"`
+	Blog     BlogConfig     `yaml:"blog"`
+	Cache    CacheConfig    `yaml:"cache"`
+	Image    ImageConfig    `yaml:"image"`
+	Publish  PublishConfig  `yaml:"publish"`
+	Log      LogConfig      `yaml:"log"`
+	Timeout  TimeoutConfig  `yaml:"timeout"`
+	MCP      MCPConfig      `yaml:"mcp"`
+	Schedule ScheduleConfig `yaml:"schedule"`
+	API      APIConfig      `yaml:"api"`
 }
 
 // WeChatConfig 微信配置
 type WeChatConfig struct {
-	AppID     string `yaml:"app_id"`
-	AppSecret string `yaml:"app_secret"`
+	AppID       string   `yaml:"app_id"`
+	AppSecret   string   `yaml:"app_secret"`
+	TestOpenIDs []string `yaml:"test_openids"` // 允许接收预览的测试用户 openid
+	// BackgroundRefresh 服务器模式下在后台提前刷新 access_token
+	BackgroundRefresh bool `yaml:"background_refresh"`
+	// 连接池配置，批量发布时复用与微信服务器的连接
+	MaxIdleConns    int `yaml:"max_idle_conns"`     // 最大空闲连接数
+	MaxConnsPerHost int `yaml:"max_conns_per_host"` // 单主机最大连接数 (0=不限制)
+	IdleConnTimeout int `yaml:"idle_conn_timeout"`  // 空闲连接超时 (秒)
+	// RetryCodes 响应中出现这些 errcode 时按退避策略重试 (默认 -1 系统繁忙和 45009 限流)
+	RetryCodes []int `yaml:"retry_codes"`
+	// TokenFile 持久化 access_token 的文件，跨进程复用以节省每日获取次数 (空=仅保存在内存)。
+	// 可使用 {account} 占位符为每个账号保存独立的令牌
+	TokenFile string `yaml:"token_file"`
+	// Accounts 多个公众号账号，按名称选择；未列出的 default 账号使用上面的 app_id/app_secret
+	Accounts map[string]AccountConfig `yaml:"accounts"`
+	// DefaultAccount 未通过 -account 或 front matter 指定时使用的账号
+	DefaultAccount string `yaml:"default_account"`
 }
 
+// AccountConfig 单个公众号账号
+type AccountConfig struct {
+	AppID       string   `yaml:"app_id"`
+	AppSecret   string   `yaml:"app_secret"`
+	TestOpenIDs []string `yaml:"test_openids"` // 留空时使用 wechat.test_openids
+}
+
+// defaultAccount 未配置 default_account 时的账号名称 (与 cache.DefaultAccount 一致)
+const defaultAccount = "default"
+
 // BlogConfig 博客配置
 type BlogConfig struct {
 	SourcePath string `yaml:"source_path"`
@@ -33,23 +63,75 @@ type BlogConfig struct {
 
 // CacheConfig 缓存配置
 type CacheConfig struct {
-	StoreFile string `yaml:"store_file"`
+	StoreFile   string `yaml:"store_file"`
+	KeyStrategy string `yaml:"key_strategy"` // content, path, path+mtime
 }
 
 // ImageConfig 图片配置
 type ImageConfig struct {
-	TempDir            string `yaml:"temp_dir"`
-	PlaceholderService string `yaml:"placeholder_service"`
-	DefaultCoverSize   string `yaml:"default_cover_size"`
+	TempDir            string   `yaml:"temp_dir"`
+	PlaceholderService string   `yaml:"placeholder_service"`
+	DefaultCoverSize   string   `yaml:"default_cover_size"`
+	CheckRemote        bool     `yaml:"check_remote"`   // 发布前对远程图片发送HEAD请求检查
+	Backend            string   `yaml:"backend"`        // 正文图片存储后端: wechat, s3
+	UploadName         string   `yaml:"upload_name"`    // 素材库中的文件名: 空(原文件名), hash, folder
+	VerifyUpload       bool     `yaml:"verify_upload"`  // 上传后HEAD检查返回的地址，不可访问时重新上传
+	FallbackImage      string   `yaml:"fallback_image"` // 图片上传失败时替换使用的本地图片或URL (空=移除图片并留下提示)
+	ManifestDir        string   `yaml:"manifest_dir"`   // 单篇文章上传清单目录，用于中断后续传 (空=禁用)
+	CoverAspect        string   `yaml:"cover_aspect"`   // 封面裁剪比例，如 2.35:1、1:1 (空或 none=不裁剪)
+	CoverCrop          string   `yaml:"cover_crop"`     // 裁剪位置: center, top
+	CacheTTL           int      `yaml:"cache_ttl"`      // 图片上传缓存有效期 (小时)，过期后重新上传 (0=永不过期)
+	MaxSizeBytes       int64    `yaml:"max_size_bytes"` // 超过该大小的 JPEG/PNG 上传前压缩 (默认10MB，即微信素材上限)
+	S3                 S3Config `yaml:"s3"`
+}
+
+// S3Config S3 兼容对象存储配置
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	Prefix          string `yaml:"prefix"`
+	PublicURL       string `yaml:"public_url"` // 公开访问地址前缀 (如CDN域名)，留空使用对象地址
+	PathStyle       bool   `yaml:"path_style"` // 使用路径风格地址 (MinIO等)
 }
 
 // PublishConfig 发布配置
 type PublishConfig struct {
-	DaysBefore        int `yaml:"days_before"`
-	DaysAfter         int `yaml:"days_after"`
-	ConcurrentUploads int `yaml:"concurrent_uploads"`
-	MaxRetries        int `yaml:"max_retries"`
-	Timeout           int `yaml:"timeout"`
+	DaysBefore         int          `yaml:"days_before"`
+	DaysAfter          int          `yaml:"days_after"`
+	ConcurrentUploads  int          `yaml:"concurrent_uploads"`
+	MaxRetries         int          `yaml:"max_retries"`
+	Timeout            int          `yaml:"timeout"`
+	SkipImageCheck     bool         `yaml:"skip_image_check"`        // 跳过发布前的图片预检查
+	SaveHTMLDir        string       `yaml:"save_html_dir"`           // 保存最终HTML的目录 (空=禁用)
+	HTMLBesideSource   bool         `yaml:"save_html_beside_source"` // 将最终HTML保存在源文件同目录 (优先于 save_html_dir)
+	ShowCoverPic       *bool        `yaml:"show_cover_pic"`          // 是否在正文中显示封面 (默认 true)
+	MaxFootnotes       int          `yaml:"max_footnotes"`           // 不同链接数超过该值时改用行内链接 (0=不限制)
+	EmbedFallback      bool         `yaml:"embed_fallback"`          // 将 iframe 视频替换为可点击的封面/链接
+	LineBreaks         string       `yaml:"line_breaks"`             // 单个换行处理: 空(标准), hard, cjk
+	Interval           int          `yaml:"interval"`                // 两篇文章发布间隔 (秒)
+	RateLimitBackoff   int          `yaml:"rate_limit_backoff"`      // 遇到限流后的等待时间 (秒)，连续限流时加倍
+	SplitThreshold     int          `yaml:"split_threshold"`         // 最终HTML超过该字符数时按顶级标题拆分为系列 (0=禁用)
+	Minify             bool         `yaml:"minify"`                  // 压缩最终HTML (折叠空白、合并重复样式)
+	PreHook            string       `yaml:"pre_hook"`                // 发布前执行的命令，文件路径作为最后一个参数，非零退出则中止
+	PostHook           string       `yaml:"post_hook"`               // 发布成功后执行的命令
+	HookTimeout        int          `yaml:"hook_timeout"`            // 钩子超时时间 (秒)
+	StructureCheck     string       `yaml:"structure_check"`         // 未闭合代码块等结构问题: 空(不检查), warn, fix, error
+	QRCode             QRCodeConfig `yaml:"qr_code"`
+	Theme              string       `yaml:"theme"`                // 排版主题: default, dark, minimal, github
+	UpdateExisting     bool         `yaml:"update_existing"`      // 已发布过的文章修改后更新原草稿，而非新建
+	AutoPublish        bool         `yaml:"auto_publish"`         // 创建草稿后自动提交发布 (freepublish)
+	AutoPublishTimeout int          `yaml:"auto_publish_timeout"` // 等待发布结果的最长时间 (秒)
+	AutoDigest         bool         `yaml:"auto_digest"`          // 没有副标题时从正文提取摘要
+}
+
+// QRCodeConfig 文末原文二维码配置
+type QRCodeConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Size    int    `yaml:"size"`    // 二维码尺寸 (像素)
+	Caption string `yaml:"caption"` // 二维码下方的说明文字
 }
 
 // LogConfig 日志配置
@@ -58,6 +140,54 @@ type LogConfig struct {
 	Format   string `yaml:"format"`
 	Output   string `yaml:"output"`
 	FilePath string `yaml:"file_path"`
+	// 日志文件超过 MaxSizeMB 时切分为带时间的备份，0 表示不切分；
+	// 备份保留 MaxBackups 个、MaxAgeDays 天，0 表示不限
+	MaxSizeMB  int `yaml:"max_size_mb"`
+	MaxBackups int `yaml:"max_backups"`
+	MaxAgeDays int `yaml:"max_age_days"`
+	// Redact debug 请求日志中额外需要脱敏的查询参数 (access_token 和 secret 始终脱敏)
+	Redact []string `yaml:"redact"`
+}
+
+// TimeoutConfig 微信接口各类请求的超时时间 (秒)，重试时每次请求单独计时。
+// 其他请求 (发布、预览等) 使用 publish.timeout
+type TimeoutConfig struct {
+	Token  int `yaml:"token"`  // 获取 access_token
+	Upload int `yaml:"upload"` // 上传图片等素材
+	Draft  int `yaml:"draft"`  // 新建和更新草稿
+}
+
+// MCPConfig MCP 服务器配置
+type MCPConfig struct {
+	EnabledTools []string `yaml:"enabled_tools"` // 允许调用的工具，留空表示全部
+	ReadOnly     bool     `yaml:"read_only"`     // 只开放只读工具
+}
+
+// ScheduleConfig 定时发布配置 (-schedule 模式)
+type ScheduleConfig struct {
+	QueueFile    string `yaml:"queue_file"`    // 定时发布队列文件，重启后继续执行
+	PollInterval int    `yaml:"poll_interval"` // 扫描文章和检查到期任务的间隔 (秒)
+}
+
+// APIConfig HTTP API 服务器配置 (-http 模式)
+type APIConfig struct {
+	// RateLimit 发布和上传接口每秒允许的请求数，所有接口共享同一个令牌桶 (0 表示不限制)
+	RateLimit float64 `yaml:"rate_limit"`
+	RateBurst int     `yaml:"rate_burst"` // 允许的突发请求数
+	// APIKey 认证密钥，建议通过 ${AWP_API_KEY} 从环境变量读取，-api-key 参数优先
+	APIKey string `yaml:"api_key"`
+	// Keys 多个命名密钥，用于轮换或分发给不同客户端，名称记录在请求日志中
+	Keys []APIKeyConfig `yaml:"keys"`
+	// MetricsToken /metrics 使用的独立令牌 (供监控系统抓取)，留空时与其他接口使用相同认证
+	MetricsToken string `yaml:"metrics_token"`
+	TLSCert      string `yaml:"tls_cert"` // HTTPS 证书文件，与 tls_key 同时设置时启用 HTTPS
+	TLSKey       string `yaml:"tls_key"`  // HTTPS 私钥文件
+}
+
+// APIKeyConfig 命名的 API 密钥
+type APIKeyConfig struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
 }
 
 var globalConfig *Config
@@ -77,6 +207,53 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("parse config file: %w", err)
 	}
 
+	// 默认值
+	if cfg.Publish.Interval <= 0 {
+		cfg.Publish.Interval = 2
+	}
+	if cfg.Publish.RateLimitBackoff <= 0 {
+		cfg.Publish.RateLimitBackoff = 60
+	}
+	if cfg.Publish.QRCode.Size <= 0 {
+		cfg.Publish.QRCode.Size = 200
+	}
+	if cfg.Publish.AutoPublishTimeout <= 0 {
+		cfg.Publish.AutoPublishTimeout = 300
+	}
+	if cfg.Publish.HookTimeout <= 0 {
+		cfg.Publish.HookTimeout = 60
+	}
+	if cfg.Timeout.Token <= 0 {
+		cfg.Timeout.Token = 10
+	}
+	if cfg.Timeout.Upload <= 0 {
+		cfg.Timeout.Upload = 120
+	}
+	if cfg.Timeout.Draft <= 0 {
+		cfg.Timeout.Draft = 30
+	}
+	if cfg.Image.MaxSizeBytes <= 0 {
+		cfg.Image.MaxSizeBytes = 10 << 20
+	}
+	if cfg.WeChat.MaxIdleConns <= 0 {
+		cfg.WeChat.MaxIdleConns = 10
+	}
+	if cfg.WeChat.IdleConnTimeout <= 0 {
+		cfg.WeChat.IdleConnTimeout = 90
+	}
+	if cfg.WeChat.RetryCodes == nil {
+		cfg.WeChat.RetryCodes = []int{-1, 45009}
+	}
+	if cfg.Schedule.QueueFile == "" {
+		cfg.Schedule.QueueFile = "schedule.json"
+	}
+	if cfg.Schedule.PollInterval <= 0 {
+		cfg.Schedule.PollInterval = 60
+	}
+	if cfg.API.RateBurst <= 0 {
+		cfg.API.RateBurst = 1
+	}
+
 	// 验证必需配置
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("validate config: %w", err)
@@ -91,16 +268,92 @@ func Get() *Config {
 	return globalConfig
 }
 
+// ResolveAccount 返回实际使用的账号名称：name 为空时依次使用 wechat.default_account 和 default
+func (c *Config) ResolveAccount(name string) string {
+	if name != "" {
+		return name
+	}
+	if c.WeChat.DefaultAccount != "" {
+		return c.WeChat.DefaultAccount
+	}
+	return defaultAccount
+}
+
+// ForAccount 返回使用指定账号凭据的配置副本，name 为空时使用默认账号
+func (c *Config) ForAccount(name string) (*Config, error) {
+	name = c.ResolveAccount(name)
+
+	cfg := *c
+	cfg.WeChat.TokenFile = strings.ReplaceAll(c.WeChat.TokenFile, "{account}", name)
+
+	account, ok := c.WeChat.Accounts[name]
+	if !ok {
+		if name != defaultAccount || c.WeChat.AppID == "" {
+			return nil, fmt.Errorf("unknown wechat account %q", name)
+		}
+		return &cfg, nil
+	}
+
+	cfg.WeChat.AppID = account.AppID
+	cfg.WeChat.AppSecret = account.AppSecret
+	if len(account.TestOpenIDs) > 0 {
+		cfg.WeChat.TestOpenIDs = account.TestOpenIDs
+	}
+	return &cfg, nil
+}
+
 // Validate 验证配置
 func (c *Config) Validate() error {
-	if c.WeChat.AppID == "" || strings.Contains(c.WeChat.AppID, "${") {
-		return fmt.Errorf("WECHAT_APP_ID is required")
+	for name, account := range c.WeChat.Accounts {
+		if account.AppID == "" || strings.Contains(account.AppID, "${") {
+			return fmt.Errorf("wechat.accounts.%s.app_id is required", name)
+		}
+		if account.AppSecret == "" || strings.Contains(account.AppSecret, "${") {
+			return fmt.Errorf("wechat.accounts.%s.app_secret is required", name)
+		}
 	}
-	if c.WeChat.AppSecret == "" || strings.Contains(c.WeChat.AppSecret, "${") {
-		return fmt.Errorf("WECHAT_APP_SECRET is required")
+	// 默认账号未在 accounts 中列出时使用顶层凭据
+	if _, ok := c.WeChat.Accounts[c.ResolveAccount("")]; !ok {
+		if c.WeChat.DefaultAccount != "" && c.WeChat.DefaultAccount != defaultAccount {
+			return fmt.Errorf("wechat.default_account %q is not listed in wechat.accounts", c.WeChat.DefaultAccount)
+		}
+		if c.WeChat.AppID == "" || strings.Contains(c.WeChat.AppID, "${") {
+			return fmt.Errorf("WECHAT_APP_ID is required")
+		}
+		if c.WeChat.AppSecret == "" || strings.Contains(c.WeChat.AppSecret, "${") {
+			return fmt.Errorf("WECHAT_APP_SECRET is required")
+		}
 	}
 	if c.Blog.SourcePath == "" {
 		return fmt.Errorf("blog.source_path is required")
 	}
+	if (c.API.TLSCert == "") != (c.API.TLSKey == "") {
+		return fmt.Errorf("api.tls_cert and api.tls_key must be set together")
+	}
+	switch c.Publish.LineBreaks {
+	case "", "hard", "cjk":
+	default:
+		return fmt.Errorf("publish.line_breaks must be empty, hard or cjk")
+	}
+	switch c.Image.UploadName {
+	case "", "hash", "folder":
+	default:
+		return fmt.Errorf("image.upload_name must be empty, hash or folder")
+	}
+	switch c.Image.CoverCrop {
+	case "", "center", "top":
+	default:
+		return fmt.Errorf("image.cover_crop must be center or top")
+	}
+	switch c.Publish.StructureCheck {
+	case "", "warn", "fix", "error":
+	default:
+		return fmt.Errorf("publish.structure_check must be empty, warn, fix or error")
+	}
+	switch c.Cache.KeyStrategy {
+	case "", "content", "path", "path+mtime":
+	default:
+		return fmt.Errorf("cache.key_strategy must be one of content, path, path+mtime")
+	}
 	return nil
 }
@@ -0,0 +1,98 @@
+package dates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout 日期格式
+const DateLayout = "2006-01-02"
+
+// Resolve 解析日期表达式，支持 ISO 日期以及 today、yesterday、tomorrow、
+// -7d、+3d、-2w 等相对表达式，相对于 now 计算
+func Resolve(expr string, now time.Time) (time.Time, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch expr {
+	case "today", "now":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	if t, err := time.ParseInLocation(DateLayout, expr, now.Location()); err == nil {
+		return t, nil
+	}
+
+	if len(expr) >= 2 {
+		unit := expr[len(expr)-1]
+		n, err := strconv.Atoi(expr[:len(expr)-1])
+		if err == nil {
+			switch unit {
+			case 'd':
+				return today.AddDate(0, 0, n), nil
+			case 'w':
+				return today.AddDate(0, 0, 7*n), nil
+			case 'm':
+				return today.AddDate(0, n, 0), nil
+			}
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date expression: %q", expr)
+}
+
+// timestampLayouts front matter 中常见的时间格式 (Hexo/Jekyll/Hugo)
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	DateLayout,
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+}
+
+// ParseTimestamp 解析 front matter 中的时间，未带时区的按 loc 解释
+func ParseTimestamp(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp: %q", value)
+}
+
+// ResolveOptional 解析日期表达式，空字符串返回零值 (表示不限制)
+func ResolveOptional(expr string, now time.Time) (time.Time, error) {
+	if strings.TrimSpace(expr) == "" {
+		return time.Time{}, nil
+	}
+	return Resolve(expr, now)
+}
+
+// InDayRange 判断 t 是否落在 start 当天零点到 end 当天结束之间 (按天比较，含两端)。
+// start、end 为零值时该端不限制
+func InDayRange(t, start, end time.Time) bool {
+	if !start.IsZero() && t.Before(startOfDay(start)) {
+		return false
+	}
+	if !end.IsZero() && !t.Before(startOfDay(end).AddDate(0, 0, 1)) {
+		return false
+	}
+	return true
+}
+
+// startOfDay 返回 t 所在日期的零点
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -39,7 +39,7 @@ func NewLogger(cfg *config.LogConfig) (*Logger, error) {
 			return nil, err
 		}
 
-		file, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		file, err := newRotatingFile(cfg.FilePath, cfg.MaxSizeMB, cfg.MaxBackups, cfg.MaxAgeDays)
 		if err != nil {
 			return nil, err
 		}
@@ -59,6 +59,6 @@ func NewLogger(cfg *config.LogConfig) (*Logger, error) {
 		handler = slog.NewTextHandler(writer, opts)
 	}
 
-	logger := slog.New(handler)
+	logger := slog.New(contextHandler{handler})
 	return &Logger{Logger: logger}, nil
 }
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat 切分后旧日志文件名中的时间格式，如 app-2024-02-15T12-00-00.000.log
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotatingFile 按大小切分的日志文件 (线程安全)。写入将超过 maxSize 时把当前文件改名为带时间的备份，
+// 再新建同名文件继续写入；备份按数量和天数清理
+type rotatingFile struct {
+	path       string
+	maxSize    int64         // 0 表示不切分
+	maxBackups int           // 保留的备份数，0 表示不限
+	maxAge     time.Duration // 备份保留时长，0 表示不限
+	mutex      sync.Mutex
+	file       *os.File
+	size       int64
+}
+
+// newRotatingFile 以追加方式打开日志文件，并按保留策略清理已有备份
+func newRotatingFile(path string, maxSizeMB, maxBackups, maxAgeDays int) (*rotatingFile, error) {
+	r := &rotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.cleanup()
+	return r, nil
+}
+
+// Write 实现 io.Writer，单条日志不会被拆到两个文件中
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// open 打开日志文件并记录已有大小 (调用方需持有锁或尚未共享)
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// rotate 将当前文件改名为备份并重新打开 (调用方需持有锁)
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+	if err := os.Rename(r.path, r.backupPath(time.Now())); err != nil {
+		return fmt.Errorf("rename log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.cleanup()
+	return nil
+}
+
+func (r *rotatingFile) backupPath(t time.Time) string {
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(r.path, ext)
+	return prefix + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// cleanup 删除超出数量或时长的备份。清理失败不影响写日志，因此忽略错误
+func (r *rotatingFile) cleanup() {
+	if r.maxBackups <= 0 && r.maxAge <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(r.path, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		path string
+		time time.Time
+	}
+	var backups []backup
+	for _, match := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(match, prefix), ext)
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue // 不是本程序生成的备份
+		}
+		backups = append(backups, backup{path: match, time: t})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].time.After(backups[j].time) })
+
+	cutoff := time.Now().Add(-r.maxAge)
+	for i, b := range backups {
+		if (r.maxBackups > 0 && i >= r.maxBackups) || (r.maxAge > 0 && b.time.Before(cutoff)) {
+			os.Remove(b.path)
+		}
+	}
+}
@@ -2,7 +2,11 @@ package markdown
 
 import (
 	"fmt"
+	"html"
+	"html/template"
+	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -12,13 +16,29 @@ import (
 
 // Beautifier HTML美化器
 type Beautifier struct {
-	cssTemplates map[string]string
+	cssTemplates  map[string]string
+	theme         string // 内置主题名称
+	templateDir   string // 外部模板目录，覆盖主题中的同名模板
+	maxFootnotes  int    // 不同链接数超过该值时保留行内链接 (0 表示不限制)
+	embedFallback bool   // 将 iframe 视频替换为可点击的封面/链接
+	minify        bool   // 压缩最终HTML
 }
 
-// NewBeautifier 创建HTML美化器
-func NewBeautifier(templateDir string) (*Beautifier, error) {
+// NewBeautifier 创建HTML美化器。theme 为内置主题名称 (空表示 default)，
+// templateDir 中的模板覆盖主题中的同名模板
+func NewBeautifier(templateDir, theme string) (*Beautifier, error) {
+	if theme == "" {
+		theme = DefaultTheme
+	}
 	b := &Beautifier{
 		cssTemplates: make(map[string]string),
+		theme:        theme,
+		templateDir:  templateDir,
+	}
+
+	// 加载主题
+	if err := b.loadTheme(theme); err != nil {
+		return nil, err
 	}
 
 	// 加载CSS模板
@@ -29,36 +49,74 @@ func NewBeautifier(templateDir string) (*Beautifier, error) {
 	return b, nil
 }
 
+// SetMaxFootnotes 设置转换为脚注的最大链接数
+func (b *Beautifier) SetMaxFootnotes(n int) {
+	b.maxFootnotes = n
+}
+
 // Beautify 美化HTML
 func (b *Beautifier) Beautify(htmlContent string) (string, error) {
+	htmlContent, _, err := b.BeautifyWithWarnings(htmlContent, nil)
+	return htmlContent, err
+}
+
+// BeautifyWithWarnings 美化HTML，并返回过程中产生的非致命问题。
+// article 提供图注和 front matter 字段，可为 nil
+func (b *Beautifier) BeautifyWithWarnings(htmlContent string, article *Article) (string, []string, error) {
+	var warnings []string
+
+	var captions, posters map[string]string
+	if article != nil {
+		captions = article.Captions
+		posters = article.Posters
+	}
+
+	// 处理微信不支持的嵌入内容
+	htmlContent, embedWarnings := b.replaceEmbeds(htmlContent, posters)
+	warnings = append(warnings, embedWarnings...)
+
 	// 包装段落
 	htmlContent = b.replaceParagraphs(htmlContent)
 
 	// 格式化标题
 	htmlContent = b.replaceHeaders(htmlContent)
 
+	// 格式化定义列表和删除线
+	htmlContent = b.formatInlineTags(htmlContent)
+
 	// 转换链接为脚注
-	htmlContent = b.replaceLinks(htmlContent)
+	htmlContent, linkWarnings := b.replaceLinks(htmlContent)
+	warnings = append(warnings, linkWarnings...)
 
 	// 格式化图片
-	htmlContent = b.formatImages(htmlContent)
+	htmlContent = b.formatImages(htmlContent, captions)
+
+	// 格式化表格
+	htmlContent = b.formatTables(htmlContent)
+
+	// 格式化引用块
+	htmlContent = b.replaceBlockquotes(htmlContent)
 
 	// 其他格式修复
 	htmlContent = b.formatFix(htmlContent)
 
 	// 添加头部和尾部
-	htmlContent = b.wrapWithTemplate(htmlContent)
+	htmlContent, err := b.wrapWithTemplate(htmlContent, article)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("header template: %v", err))
+	}
+
+	// 压缩HTML，为正文大小限制留出余量
+	if b.minify {
+		htmlContent = minifyHTML(htmlContent)
+	}
 
-	return htmlContent, nil
+	return htmlContent, warnings, nil
 }
 
 // replaceParagraphs 替换段落样式
 func (b *Beautifier) replaceParagraphs(content string) string {
-	paraStyle := b.getTemplate("para")
-	if paraStyle == "" {
-		paraStyle = `<p style="margin: 10px 0; line-height: 1.75em;">`
-	}
-	return strings.ReplaceAll(content, "<p>", paraStyle)
+	return strings.ReplaceAll(content, "<p>", b.getTemplate("para"))
 }
 
 // replaceHeaders 替换标题样式
@@ -79,70 +137,122 @@ func (b *Beautifier) replaceHeaders(content string) string {
 			fontSize = 18 + (4-int(l))*2
 		}
 
-		template := b.getTemplate("sub")
-		if template == "" {
-			return fmt.Sprintf(`<h%s style="font-size: %dpx; font-weight: bold; margin: 20px 0 10px;">%s</h%s>`,
-				level, fontSize, text, level)
-		}
-
-		return fmt.Sprintf(template, level, fontSize, text, level)
+		return fmt.Sprintf(b.getTemplate("sub"), level, fontSize, text, level)
 	})
 }
 
-// replaceLinks 替换链接为脚注
-func (b *Beautifier) replaceLinks(content string) string {
+// inlineTags 定义列表和删除线标签，模板名即标签名
+var inlineTags = []string{"dl", "dt", "dd", "del"}
+
+// formatInlineTags 为定义列表 (dl/dt/dd) 和删除线 (del) 添加样式
+func (b *Beautifier) formatInlineTags(content string) string {
+	for _, tag := range inlineTags {
+		content = strings.ReplaceAll(content, "<"+tag+">", b.getTemplate(tag))
+	}
+	return content
+}
+
+// footnoteLink 脚注中的一条链接，文字取该地址第一次出现时的链接文字
+type footnoteLink struct {
+	href string
+	text string
+}
+
+// isExternalLink 判断是否为需要转换为脚注的外部 http(s) 链接
+func isExternalLink(href string) bool {
+	lower := strings.ToLower(strings.TrimSpace(href))
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// replaceLinks 替换链接为脚注。通过节点操作替换，不受链接属性 (target、rel 等) 影响，
+// 相同地址的链接共用一个脚注编号。只有 http(s) 链接转换为脚注，
+// 页内锚点 (标题锚点、脚注回链)、mailto:、tel: 等链接在微信中无法使用，保留为纯文本
+func (b *Beautifier) replaceLinks(content string) (string, []string) {
+	if !strings.Contains(content, "<a") {
+		return content, nil
+	}
+
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
 	if err != nil {
-		return content
+		return content, nil
 	}
 
-	links := make([]struct {
-		href string
-		text string
-	}, 0)
+	anchors := doc.Find("a[href]")
+	if anchors.Length() == 0 {
+		return content, nil
+	}
 
-	doc.Find("a").Each(func(i int, s *goquery.Selection) {
+	external := anchors.FilterFunction(func(i int, s *goquery.Selection) bool {
 		href, _ := s.Attr("href")
-		text := s.Text()
-		links = append(links, struct {
-			href string
-			text string
-		}{href, text})
+		return isExternalLink(href)
 	})
+	internal := anchors.NotSelection(external)
 
-	if len(links) == 0 {
-		return content
+	var links []footnoteLink
+	numbers := make(map[string]int)
+	external.Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		if _, ok := numbers[href]; ok {
+			return
+		}
+		links = append(links, footnoteLink{href: href, text: s.Text()})
+		numbers[href] = len(links)
+	})
+
+	// 非外部链接去掉 <a>，保留其中的文字和格式
+	internal.Each(func(i int, s *goquery.Selection) {
+		s.ReplaceWithSelection(s.Contents())
+	})
+
+	var warnings []string
+	if b.maxFootnotes > 0 && len(links) > b.maxFootnotes {
+		// 链接过多时脚注区会比正文还长，改为保留行内链接
+		slog.Info("Too many links for footnotes, keeping inline links",
+			"links", len(links), "max_footnotes", b.maxFootnotes)
+		warnings = append(warnings, fmt.Sprintf(
+			"%d links exceed max_footnotes (%d), kept as inline links", len(links), b.maxFootnotes))
+		links = nil
+	} else {
+		// 替换链接为脚注引用，保留链接内的格式
+		external.Each(func(i int, s *goquery.Selection) {
+			href, _ := s.Attr("href")
+			inner, err := s.Html()
+			if err != nil {
+				inner = html.EscapeString(s.Text())
+			}
+			s.ReplaceWithHtml(fmt.Sprintf(`%s<sup>[%d]</sup>`, inner, numbers[href]))
+		})
 	}
 
-	// 替换链接为脚注引用
-	for i, link := range links {
-		oldLink := fmt.Sprintf(`<a href="%s">%s</a>`, link.href, link.text)
-		newLink := fmt.Sprintf(`%s<sup>[%d]</sup>`, link.text, i+1)
-		content = strings.ReplaceAll(content, oldLink, newLink)
+	body, err := doc.Find("body").Html()
+	if err != nil {
+		return content, nil
+	}
+	if len(links) == 0 {
+		return body, warnings
 	}
 
 	// 添加脚注区域
-	refHeader := b.getTemplate("ref_header")
-	if refHeader == "" {
-		refHeader = `<hr style="margin: 30px 0;"/><h4>参考链接</h4>`
-	}
-	content += "\n" + refHeader
-	content += `<section class="footnotes">`
+	var sb strings.Builder
+	sb.WriteString(body)
+	sb.WriteString("\n" + b.getTemplate("ref_header"))
+	sb.WriteString(`<section class="footnotes">`)
 
+	refLink := b.getTemplate("ref_link")
 	for i, link := range links {
-		refLink := b.getTemplate("ref_link")
-		if refLink == "" {
-			refLink = `<p>[%d] %s: <a href="%s">%s</a></p>`
-		}
-		content += fmt.Sprintf(refLink, i+1, link.text, link.href, link.href)
+		href := html.EscapeString(link.href)
+		fmt.Fprintf(&sb, refLink, i+1, html.EscapeString(link.text), href, href)
 	}
 
-	content += "</section>"
-	return content
+	sb.WriteString("</section>")
+	return sb.String(), nil
 }
 
+// figcaptionPattern 匹配图注，图注为空时整体移除
+var figcaptionPattern = regexp.MustCompile(`(?s)\s*<figcaption[^>]*>.*?</figcaption>`)
+
 // formatImages 格式化图片
-func (b *Beautifier) formatImages(content string) string {
+func (b *Beautifier) formatImages(content string, captions map[string]string) string {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
 	if err != nil {
 		return content
@@ -152,34 +262,49 @@ func (b *Beautifier) formatImages(content string) string {
 		alt, _ := s.Attr("alt")
 		src, _ := s.Attr("src")
 
-		oldImg := fmt.Sprintf(`<img alt="%s" src="%s" />`, alt, src)
-
-		figureTemplate := b.getTemplate("figure")
-		if figureTemplate == "" {
-			figureTemplate = `<figure style="text-align: center; margin: 20px 0;">
-				<img alt="%s" src="%s" style="max-width: 100%%; border-radius: 8px;" />
-				<figcaption style="margin-top: 10px; color: #666; font-size: 14px;">%s</figcaption>
-			</figure>`
+		// gomarkdown 输出 src 在前，兼容两种属性顺序
+		oldImgs := []string{
+			fmt.Sprintf(`<img alt="%s" src="%s" />`, html.EscapeString(alt), html.EscapeString(src)),
+			fmt.Sprintf(`<img src="%s" alt="%s" />`, html.EscapeString(src), html.EscapeString(alt)),
 		}
 
-		newImg := fmt.Sprintf(figureTemplate, alt, src, alt)
-		content = strings.ReplaceAll(content, oldImg, newImg)
+		caption := lookupCaption(captions, src, alt)
+		newImg := fmt.Sprintf(b.getTemplate("figure"),
+			html.EscapeString(alt), html.EscapeString(src), html.EscapeString(caption))
+		if caption == "" {
+			newImg = figcaptionPattern.ReplaceAllString(newImg, "")
+		}
+		for _, oldImg := range oldImgs {
+			content = strings.ReplaceAll(content, oldImg, newImg)
+		}
 	})
 
 	return content
 }
 
+// lookupCaption 按图片地址、文件名依次查找图注，未配置时使用 alt
+func lookupCaption(captions map[string]string, src, alt string) string {
+	if caption, ok := captions[src]; ok {
+		return caption
+	}
+	if caption, ok := captions[path.Base(src)]; ok {
+		return caption
+	}
+	return alt
+}
+
+// QRFooter 生成文末二维码区块，模板参数依次为图片地址、宽度和说明文字
+func (b *Beautifier) QRFooter(src string, size int, caption string) string {
+	return fmt.Sprintf(b.getTemplate("qr_footer"), html.EscapeString(src), size, html.EscapeString(caption))
+}
+
 // formatFix 其他格式修复
 func (b *Beautifier) formatFix(content string) string {
 	// 列表项之间添加间距
 	content = strings.ReplaceAll(content, "</li>", "</li>\n<p></p>")
 
 	// 代码块样式
-	codeStyle := b.getTemplate("code")
-	if codeStyle == "" {
-		codeStyle = `background: #272822; padding: 15px; border-radius: 5px; overflow-x: auto;`
-	}
-	content = strings.ReplaceAll(content, `background: #272822`, codeStyle)
+	content = strings.ReplaceAll(content, `background: #272822`, b.getTemplate("code"))
 
 	// 预格式化文本样式
 	content = strings.ReplaceAll(content,
@@ -189,26 +314,48 @@ func (b *Beautifier) formatFix(content string) string {
 	return content
 }
 
-// wrapWithTemplate 用模板包装内容
-func (b *Beautifier) wrapWithTemplate(content string) string {
+// wrapWithTemplate 用模板包装内容。header 模板包含 {{ }} 时按 html/template 渲染，
+// 可引用 .Title、.Subtitle、.Author、.Date 和 .Meta.<字段>
+func (b *Beautifier) wrapWithTemplate(content string, article *Article) (string, error) {
 	header := b.getTemplate("header")
-	if header == "" {
-		header = `<section style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; 
-			font-size: 16px; color: #333; padding: 20px; max-width: 800px; margin: 0 auto;">`
+	if strings.Contains(header, "{{") {
+		if article == nil {
+			article = &Article{}
+		}
+		rendered, err := renderHeader(header, article)
+		if err != nil {
+			// 模板有误时退回默认头部，避免原样输出模板语法
+			return defaultTemplates["header"] + content + "</section>", err
+		}
+		header = rendered
 	}
-	return header + content + "</section>"
+
+	return header + content + "</section>", nil
 }
 
-// loadTemplates 加载CSS模板
+// renderHeader 使用文章字段渲染 header 模板
+func renderHeader(header string, article *Article) (string, error) {
+	tmpl, err := template.New("header").Option("missingkey=zero").Parse(header)
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, article); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// loadTemplates 加载外部CSS模板，与内置默认模板合并：目录中存在的同名文件覆盖默认模板，
+// 其余模板继续使用内置版本
 func (b *Beautifier) loadTemplates(templateDir string) error {
 	if templateDir == "" || !fileExists(templateDir) {
 		// 使用默认模板
 		return nil
 	}
 
-	templates := []string{"para", "sub", "link", "ref_header", "ref_link", "figure", "code", "header"}
-
-	for _, name := range templates {
+	for name := range defaultTemplates {
 		path := filepath.Join(templateDir, name+".tmpl")
 		if fileExists(path) {
 			content, err := os.ReadFile(path)
@@ -222,12 +369,12 @@ func (b *Beautifier) loadTemplates(templateDir string) error {
 	return nil
 }
 
-// getTemplate 获取模板
+// getTemplate 获取模板，没有外部或主题模板 (或内容为空) 时使用内置默认模板
 func (b *Beautifier) getTemplate(name string) string {
-	if tmpl, ok := b.cssTemplates[name]; ok {
+	if tmpl := b.cssTemplates[name]; tmpl != "" {
 		return tmpl
 	}
-	return ""
+	return defaultTemplates[name]
 }
 
 // fileExists 检查文件是否存在
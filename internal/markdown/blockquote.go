@@ -0,0 +1,35 @@
+package markdown
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// replaceBlockquotes 为引用块添加卡片样式 (左边框、浅色背景、斜体)。
+// 嵌套的引用块使用相同样式并缩小外边距
+func (b *Beautifier) replaceBlockquotes(content string) string {
+	if !strings.Contains(content, "<blockquote") {
+		return content
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+
+	style := strings.TrimSpace(b.getTemplate("quote"))
+
+	doc.Find("blockquote").Each(func(i int, quote *goquery.Selection) {
+		addStyle(quote, style)
+		if quote.ParentsFiltered("blockquote").Length() > 0 {
+			addStyle(quote, "margin: 10px 0;")
+		}
+	})
+
+	html, err := doc.Find("body").Html()
+	if err != nil {
+		return content
+	}
+	return html
+}
@@ -0,0 +1,56 @@
+package markdown
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// sentenceEnds 可作为摘要截断位置的句末标点
+const sentenceEnds = "。！？；.!?;"
+
+// Digest 从文章 HTML 中提取纯文本摘要，最多 maxRunes 个字符。
+// 优先使用段落文字 (跳过标题、代码块和脚注)，折叠空白后在句子或单词边界处截断
+func Digest(htmlContent string, maxRunes int) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return ""
+	}
+	doc.Find("pre, script, style, .footnotes, sup.footnote-ref").Remove()
+
+	var parts []string
+	doc.Find("p").Each(func(i int, s *goquery.Selection) {
+		if text := strings.TrimSpace(s.Text()); text != "" {
+			parts = append(parts, text)
+		}
+	})
+	if len(parts) == 0 {
+		parts = append(parts, doc.Find("body").Text())
+	}
+
+	text := strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+	return truncateText(text, maxRunes)
+}
+
+// truncateText 将文本截断到 maxRunes 个字符以内。后半部分有句末标点时在其后截断，
+// 否则在最后一个空格处截断并加省略号，都没有时 (如连续中文) 直接截断
+func truncateText(text string, maxRunes int) string {
+	runes := []rune(text)
+	if maxRunes <= 0 || len(runes) <= maxRunes {
+		return text
+	}
+
+	// 为省略号预留一个字符
+	cut := runes[:maxRunes-1]
+	for i := len(cut) - 1; i >= len(cut)/2; i-- {
+		if strings.ContainsRune(sentenceEnds, cut[i]) {
+			return string(runes[:i+1])
+		}
+	}
+	for i := len(cut) - 1; i >= len(cut)/2; i-- {
+		if cut[i] == ' ' {
+			return strings.TrimSpace(string(cut[:i])) + "…"
+		}
+	}
+	return string(cut) + "…"
+}
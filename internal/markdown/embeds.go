@@ -0,0 +1,140 @@
+package markdown
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// embedPatterns 微信会静默删除的元素，逐个标签匹配以保持其余HTML原样
+var embedPatterns = func() []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, tag := range []string{"script", "iframe", "form", "textarea", "select", "button", "object"} {
+		patterns = append(patterns, regexp.MustCompile(`(?is)<`+tag+`\b[^>]*?(?:/>|>.*?</`+tag+`\s*>)`))
+	}
+	for _, tag := range []string{"input", "embed"} {
+		patterns = append(patterns, regexp.MustCompile(`(?is)<`+tag+`\b[^>]*>`))
+	}
+	return patterns
+}()
+
+var (
+	embedTagRe = regexp.MustCompile(`^<([a-zA-Z]+)`)
+	embedSrcRe = regexp.MustCompile(`(?i)\bsrc\s*=\s*["']([^"']*)["']`)
+)
+
+// SetEmbedFallback 设置是否将 iframe 视频替换为可点击的封面/链接
+func (b *Beautifier) SetEmbedFallback(enabled bool) {
+	b.embedFallback = enabled
+}
+
+// VideoPosters 返回正文中 iframe 视频的封面地址。
+// 微信不显示外链图片，封面需要与正文图片一样上传后才能使用
+func VideoPosters(content string) []string {
+	var posters []string
+	seen := make(map[string]bool)
+	for _, re := range embedPatterns {
+		for _, match := range re.FindAllString(content, -1) {
+			if !strings.EqualFold(embedTagRe.FindStringSubmatch(match)[1], "iframe") {
+				continue
+			}
+			m := embedSrcRe.FindStringSubmatch(match)
+			if m == nil {
+				continue
+			}
+			if _, poster, _ := videoLink(m[1]); poster != "" && !seen[poster] {
+				seen[poster] = true
+				posters = append(posters, poster)
+			}
+		}
+	}
+	return posters
+}
+
+// replaceEmbeds 处理微信不支持的嵌入内容，并输出被移除内容的警告。
+// posters 为视频封面原地址到可用地址的映射
+func (b *Beautifier) replaceEmbeds(content string, posters map[string]string) (string, []string) {
+	var stripped []string
+
+	for _, re := range embedPatterns {
+		content = re.ReplaceAllStringFunc(content, func(match string) string {
+			tag := strings.ToLower(embedTagRe.FindStringSubmatch(match)[1])
+			src := ""
+			if m := embedSrcRe.FindStringSubmatch(match); m != nil {
+				src = m[1]
+			}
+
+			if tag == "iframe" && b.embedFallback && src != "" {
+				stripped = append(stripped, fmt.Sprintf("iframe(%s) -> link", src))
+				return b.embedFallbackHTML(src, posters)
+			}
+
+			if src != "" {
+				stripped = append(stripped, fmt.Sprintf("%s(%s)", tag, src))
+			} else {
+				stripped = append(stripped, tag)
+			}
+			return ""
+		})
+	}
+
+	if len(stripped) == 0 {
+		return content, nil
+	}
+
+	slog.Warn("Removed elements unsupported by WeChat", "elements", stripped)
+	warnings := make([]string, len(stripped))
+	for i, element := range stripped {
+		warnings[i] = "removed unsupported element: " + element
+	}
+	return content, warnings
+}
+
+// embedFallbackHTML 为 iframe 生成替代内容，已知视频站点的封面在 posters 中有可用地址时生成可点击的封面，
+// 否则生成文字链接
+func (b *Beautifier) embedFallbackHTML(src string, posters map[string]string) string {
+	link, poster, provider := videoLink(src)
+
+	if uploaded := posters[poster]; poster != "" && uploaded != "" {
+		return fmt.Sprintf(`<p style="text-align: center; margin: 20px 0;"><a href="%s"><img alt="%s" src="%s" style="max-width: 100%%; border-radius: 8px;" /></a><br/><span style="color: #666; font-size: 14px;">▶ 点击观看 %s 视频</span></p>`,
+			link, provider, uploaded, provider)
+	}
+
+	return fmt.Sprintf(`<p style="margin: 20px 0; padding: 12px 16px; background: #f6f8fa; border-radius: 8px; text-align: center;">▶ <a href="%s">观看 %s 视频</a></p>`,
+		link, provider)
+}
+
+// videoLink 识别常见视频站点的嵌入地址，返回观看地址、封面地址和站点名
+func videoLink(src string) (link, poster, provider string) {
+	if strings.HasPrefix(src, "//") {
+		src = "https:" + src
+	}
+
+	u, err := url.Parse(src)
+	if err != nil {
+		return src, "", "嵌入"
+	}
+
+	host := strings.TrimPrefix(u.Host, "www.")
+	switch {
+	case host == "youtube.com" || host == "youtube-nocookie.com":
+		if id := strings.TrimPrefix(u.Path, "/embed/"); id != u.Path && id != "" {
+			return "https://www.youtube.com/watch?v=" + id,
+				"https://img.youtube.com/vi/" + id + "/hqdefault.jpg",
+				"YouTube"
+		}
+		return src, "", "YouTube"
+	case host == "player.bilibili.com":
+		if bvid := u.Query().Get("bvid"); bvid != "" {
+			return "https://www.bilibili.com/video/" + bvid, "", "Bilibili"
+		}
+		if aid := u.Query().Get("aid"); aid != "" {
+			return "https://www.bilibili.com/video/av" + aid, "", "Bilibili"
+		}
+		return src, "", "Bilibili"
+	default:
+		return src, "", "嵌入"
+	}
+}
@@ -0,0 +1,292 @@
+package markdown
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatter front matter 中的已知字段，其余字段保存在 Extra 中
+type frontMatter struct {
+	Title        string            `yaml:"title"`
+	Subtitle     string            `yaml:"subtitle"`
+	Date         string            `yaml:"date"`
+	Updated      string            `yaml:"updated"`
+	PublishAt    string            `yaml:"publish_at"`
+	Author       string            `yaml:"author"`
+	GenCover     string            `yaml:"gen_cover"`
+	ShowCover    string            `yaml:"show_cover"`
+	QRCode       string            `yaml:"qr_code"`
+	TagID        string            `yaml:"tag_id"`
+	Account      string            `yaml:"account"`
+	Theme        string            `yaml:"theme"`
+	Order        string            `yaml:"order"`
+	CanonicalURL string            `yaml:"canonical_url"`
+	OriginalURL  string            `yaml:"original_url"`
+	Captions     map[string]string `yaml:"captions"`
+	Tags         stringList        `yaml:"tags"`
+	Categories   stringList        `yaml:"categories"`
+
+	Extra map[string]interface{} `yaml:",inline"`
+}
+
+// frontMatterFormat front matter 格式
+type frontMatterFormat string
+
+const (
+	formatNone frontMatterFormat = ""
+	formatYAML frontMatterFormat = "yaml" // --- 分隔
+	formatTOML frontMatterFormat = "toml" // +++ 分隔
+	formatJSON frontMatterFormat = "json" // 以 { 开头的 JSON 对象
+)
+
+// extractFrontMatter 提取并解码 front matter，返回元数据和正文
+func (p *Parser) extractFrontMatter(content string) (*frontMatter, string) {
+	fm := &frontMatter{}
+
+	raw, body, format := splitFrontMatter(content)
+	switch format {
+	case formatNone:
+		return fm, body
+	case formatYAML:
+		if err := yaml.Unmarshal([]byte(raw), fm); err != nil {
+			// 兼容未加引号且包含冒号等不合法 YAML 的旧文章，退回按行解析
+			slog.Warn("Invalid YAML front matter, falling back to line parser", "error", err)
+			fm = parseFrontMatterLines(raw)
+		}
+	case formatTOML:
+		values := make(map[string]interface{})
+		if _, err := toml.Decode(raw, &values); err != nil {
+			slog.Warn("Invalid TOML front matter, ignoring metadata", "error", err)
+			break
+		}
+		fm = frontMatterFromMap(values)
+	case formatJSON:
+		values := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(raw), &values); err != nil {
+			slog.Warn("Invalid JSON front matter, ignoring metadata", "error", err)
+			break
+		}
+		fm = frontMatterFromMap(values)
+	}
+
+	return fm, strings.TrimSpace(body)
+}
+
+// frontMatterFromMap 将 TOML/JSON 解码出的映射转换为 frontMatter，
+// 经由 YAML 重新编码以复用字段标签和列表兼容逻辑
+func frontMatterFromMap(values map[string]interface{}) *frontMatter {
+	data, err := yaml.Marshal(normalizeValue(values))
+	if err != nil {
+		return &frontMatter{}
+	}
+	fm := &frontMatter{}
+	if err := yaml.Unmarshal(data, fm); err != nil {
+		return &frontMatter{}
+	}
+	return fm
+}
+
+// normalizeValue 将时间值格式化为与 YAML front matter 一致的字符串
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		return formatTime(v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = normalizeValue(item)
+		}
+		return items
+	case []map[string]interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = normalizeValue(item)
+		}
+		return items
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = normalizeValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// formatTime 格式化 front matter 中的时间，零点时只保留日期
+func formatTime(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// metadata 将全部字段转换为字符串映射，供模板通过 .Meta 引用。
+// 列表以逗号连接，嵌套映射忽略
+func (fm *frontMatter) metadata() map[string]string {
+	metadata := make(map[string]string)
+
+	known := map[string]string{
+		"title":         fm.Title,
+		"subtitle":      fm.Subtitle,
+		"date":          fm.Date,
+		"updated":       fm.Updated,
+		"publish_at":    fm.PublishAt,
+		"author":        fm.Author,
+		"gen_cover":     fm.GenCover,
+		"show_cover":    fm.ShowCover,
+		"qr_code":       fm.QRCode,
+		"tag_id":        fm.TagID,
+		"account":       fm.Account,
+		"theme":         fm.Theme,
+		"order":         fm.Order,
+		"canonical_url": fm.CanonicalURL,
+		"original_url":  fm.OriginalURL,
+		"tags":          strings.Join(fm.Tags, ", "),
+		"categories":    strings.Join(fm.Categories, ", "),
+	}
+	for key, value := range known {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+
+	for key, value := range fm.Extra {
+		if s, ok := metaString(value); ok {
+			metadata[key] = s
+		}
+	}
+
+	return metadata
+}
+
+// metaString 将 YAML 解码出的值格式化为字符串
+func metaString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case time.Time:
+		return formatTime(v), true
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := metaString(item); ok && s != "" {
+				items = append(items, s)
+			}
+		}
+		return strings.Join(items, ", "), true
+	case map[string]interface{}:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// parseFrontMatterLines 按 "key: value" 逐行解析 front matter (旧解析方式)
+func parseFrontMatterLines(yamlContent string) *frontMatter {
+	lines := make(map[string]interface{})
+	scanner := bufio.NewScanner(strings.NewReader(yamlContent))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		lines[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+
+	// 借助 YAML 将字符串映射映射到结构体字段
+	data, err := yaml.Marshal(lines)
+	if err != nil {
+		return &frontMatter{}
+	}
+	fm := &frontMatter{}
+	if err := yaml.Unmarshal(data, fm); err != nil {
+		return &frontMatter{}
+	}
+	return fm
+}
+
+// splitFrontMatter 根据开头的分隔符识别 front matter 格式，拆分出原始内容和正文。
+// 无法识别时返回 formatNone，整个内容作为正文
+func splitFrontMatter(content string) (string, string, frontMatterFormat) {
+	// 1. 去除 BOM 头
+	content = strings.TrimPrefix(content, "\ufeff")
+
+	// 2. 统一换行符为 \n，解决 Windows CRLF 问题
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	// 3. 按开头的分隔符识别格式
+	switch {
+	case strings.HasPrefix(content, "---\n"):
+		if raw, body, ok := splitDelimited(content, "---"); ok {
+			return raw, body, formatYAML
+		}
+	case strings.HasPrefix(content, "+++\n"):
+		if raw, body, ok := splitDelimited(content, "+++"); ok {
+			return raw, body, formatTOML
+		}
+	case strings.HasPrefix(content, "{"):
+		// JSON 对象本身即为 front matter，解码到对象结束的位置
+		decoder := json.NewDecoder(strings.NewReader(content))
+		var values json.RawMessage
+		if err := decoder.Decode(&values); err == nil {
+			offset := decoder.InputOffset()
+			return content[:offset], content[offset:], formatJSON
+		}
+	}
+
+	return "", content, formatNone
+}
+
+// splitDelimited 拆分由一对 delim 行包围的 front matter
+func splitDelimited(content, delim string) (string, string, bool) {
+	start := len(delim) + 1
+
+	// 查找结束分隔符
+	endIndex := strings.Index(content[start:], "\n"+delim+"\n")
+	if endIndex == -1 {
+		// 尝试查找文件结尾的分隔符
+		if !strings.HasSuffix(content, "\n"+delim) || len(content) < 2*start {
+			return "", "", false
+		}
+		return content[start : len(content)-start], "", true
+	}
+
+	raw := content[start : start+endIndex]
+	body := content[start+endIndex+len(delim)+2:] // 跳过 \n<delim>\n
+	return raw, body, true
+}
+
+// stringList 兼容 [a, b]、缩进列表和单个字符串三种写法，去除引号并跳过空项
+type stringList []string
+
+// UnmarshalYAML 实现 yaml.Unmarshaler
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	var items []string
+	switch node.Kind {
+	case yaml.SequenceNode:
+		if err := node.Decode(&items); err != nil {
+			return err
+		}
+	case yaml.ScalarNode:
+		items = []string{node.Value}
+	}
+
+	for _, item := range items {
+		item = strings.Trim(strings.TrimSpace(item), `"'`)
+		if item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
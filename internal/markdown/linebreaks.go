@@ -0,0 +1,61 @@
+package markdown
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LineBreakMode 单个换行的处理方式
+type LineBreakMode string
+
+const (
+	// LineBreakStandard 标准 Markdown，单个换行合并为空格
+	LineBreakStandard LineBreakMode = ""
+	// LineBreakHard 所有单个换行都渲染为 <br>
+	LineBreakHard LineBreakMode = "hard"
+	// LineBreakCJK 仅在前后两行都是中日韩文字时渲染为 <br>
+	LineBreakCJK LineBreakMode = "cjk"
+)
+
+// SetLineBreakMode 设置单个换行的处理方式
+func (p *Parser) SetLineBreakMode(mode LineBreakMode) {
+	p.lineBreakMode = mode
+}
+
+// insertCJKBreaks 在相邻的中文行之间插入 Markdown 硬换行 (行尾两个空格)，跳过代码块
+func insertCJKBreaks(content string) string {
+	lines := strings.Split(content, "\n")
+	inFence := false
+
+	for i := 0; i < len(lines)-1; i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		next := strings.TrimSpace(lines[i+1])
+		if trimmed == "" || next == "" {
+			continue
+		}
+
+		last, _ := utf8.DecodeLastRuneInString(trimmed)
+		first, _ := utf8.DecodeRuneInString(next)
+		if isCJK(last) && isCJK(first) {
+			lines[i] = strings.TrimRight(lines[i], " \t") + "  "
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// isCJK 判断是否为中日韩文字或全角标点
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK 标点
+		(r >= 0xFF00 && r <= 0xFFEF) // 全角字符
+}
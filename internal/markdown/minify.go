@@ -0,0 +1,83 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// preBlockPattern 匹配 <pre> 代码块，其中的空白有意义，压缩时原样保留
+	preBlockPattern = regexp.MustCompile(`(?is)<pre[\s>].*?</pre>`)
+	// whitespacePattern 连续空白
+	whitespacePattern = regexp.MustCompile(`\s+`)
+	// blockTagSpacePattern 块级标签前后的空白不影响渲染
+	blockTagSpacePattern = regexp.MustCompile(
+		`\s*(</?(?:section|p|figure|figcaption|h[1-6]|ul|ol|li|dl|dt|dd|hr|div|table|thead|tbody|tr|td|th|blockquote)\b[^>]*>)\s*`)
+	// styleAttrPattern 行内样式属性
+	styleAttrPattern = regexp.MustCompile(`style="([^"]*)"`)
+)
+
+// SetMinify 设置是否压缩最终HTML
+func (b *Beautifier) SetMinify(minify bool) {
+	b.minify = minify
+}
+
+// minifyHTML 压缩HTML：折叠空白、去掉块级标签间的空白、合并重复的样式声明。
+// <pre> 中的内容保持不变
+func minifyHTML(content string) string {
+	var out strings.Builder
+	last := 0
+	for _, loc := range preBlockPattern.FindAllStringIndex(content, -1) {
+		out.WriteString(minifySegment(content[last:loc[0]]))
+		out.WriteString(content[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	out.WriteString(minifySegment(content[last:]))
+	return out.String()
+}
+
+// minifySegment 压缩不含 <pre> 的片段
+func minifySegment(segment string) string {
+	segment = whitespacePattern.ReplaceAllString(segment, " ")
+	segment = blockTagSpacePattern.ReplaceAllString(segment, "$1")
+	return styleAttrPattern.ReplaceAllStringFunc(segment, func(attr string) string {
+		style := styleAttrPattern.FindStringSubmatch(attr)[1]
+		return `style="` + dedupeStyle(style) + `"`
+	})
+}
+
+// dedupeStyle 同一属性出现多次时只保留最后一次 (与浏览器的生效规则一致)
+func dedupeStyle(style string) string {
+	// 含 url()、引号或实体 (如 &#39; 转义的引号) 等可能带分号的值时不处理
+	if strings.ContainsAny(style, "(&'\\") {
+		return strings.TrimSpace(style)
+	}
+
+	type decl struct {
+		prop  string
+		value string
+	}
+	var decls []decl
+	index := make(map[string]int)
+	for _, part := range strings.Split(style, ";") {
+		prop, value, ok := strings.Cut(part, ":")
+		if !ok {
+			continue
+		}
+		prop = strings.ToLower(strings.TrimSpace(prop))
+		value = strings.TrimSpace(value)
+		if i, exists := index[prop]; exists {
+			decls[i].prop = ""
+		}
+		index[prop] = len(decls)
+		decls = append(decls, decl{prop, value})
+	}
+
+	parts := make([]string, 0, len(decls))
+	for _, d := range decls {
+		if d.prop != "" {
+			parts = append(parts, d.prop+": "+d.value)
+		}
+	}
+	return strings.Join(parts, "; ") + ";"
+}
@@ -0,0 +1,126 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const minifySample = `<section style="margin: 0; padding: 8px; margin: 4px 0;">
+  <h2 style="color: #333;   font-size: 18px;">  标题  </h2>
+  <p style="line-height: 1.75; color: red; color: #3f3f3f;">
+    第一段 <strong style="font-weight: bold;">加粗</strong>   文字
+  </p>
+
+  <pre style="background: #f6f8fa;"><code>func main() {
+    fmt.Println("  保留空白  ")
+}
+</code></pre>
+  <ul>
+    <li>  一  </li>
+    <li>二</li>
+  </ul>
+</section>
+`
+
+// renderedElement 一个元素在视觉上有意义的部分：标签、最终生效的样式和自身文本
+type renderedElement struct {
+	tag   string
+	style map[string]string
+	text  string
+}
+
+// renderedElements 按文档顺序列出所有元素
+func renderedElements(t *testing.T, content string) []renderedElement {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("parse html: %v", err)
+	}
+
+	var elements []renderedElement
+	doc.Find("body *").Each(func(_ int, s *goquery.Selection) {
+		style, _ := s.Attr("style")
+		var text strings.Builder
+		s.Contents().Each(func(_ int, c *goquery.Selection) {
+			if goquery.NodeName(c) == "#text" {
+				text.WriteString(c.Text())
+			}
+		})
+		own := text.String()
+		if s.Closest("pre").Length() == 0 {
+			own = strings.Join(strings.Fields(own), " ")
+		}
+		elements = append(elements, renderedElement{
+			tag:   goquery.NodeName(s),
+			style: effectiveStyle(style),
+			text:  own,
+		})
+	})
+	return elements
+}
+
+// effectiveStyle 解析行内样式，同一属性以最后一次声明为准
+func effectiveStyle(style string) map[string]string {
+	decls := make(map[string]string)
+	for _, part := range strings.Split(style, ";") {
+		prop, value, ok := strings.Cut(part, ":")
+		if !ok {
+			continue
+		}
+		decls[strings.ToLower(strings.TrimSpace(prop))] = strings.TrimSpace(value)
+	}
+	return decls
+}
+
+func TestMinifyHTMLPreservesRendering(t *testing.T) {
+	minified := minifyHTML(minifySample)
+
+	if len(minified) >= len(minifySample) {
+		t.Errorf("minified length %d, want less than %d", len(minified), len(minifySample))
+	}
+
+	before := renderedElements(t, minifySample)
+	after := renderedElements(t, minified)
+	if len(before) != len(after) {
+		t.Fatalf("element count changed: %d -> %d", len(before), len(after))
+	}
+	for i := range before {
+		if !reflect.DeepEqual(before[i], after[i]) {
+			t.Errorf("element %d changed:\nbefore %+v\nafter  %+v", i, before[i], after[i])
+		}
+	}
+}
+
+func TestMinifyHTMLKeepsPreVerbatim(t *testing.T) {
+	start := strings.Index(minifySample, "<pre")
+	end := strings.Index(minifySample, "</pre>") + len("</pre>")
+	pre := minifySample[start:end]
+
+	if !strings.Contains(minifyHTML(minifySample), pre) {
+		t.Errorf("pre block was modified, want it kept verbatim:\n%s", pre)
+	}
+}
+
+func TestMinifyHTMLKeepsQuotedStyle(t *testing.T) {
+	for _, style := range []string{
+		`font-family: 'Menlo', monospace; color: red`,
+		`font-family: &#39;Menlo&#39;, monospace; color: red`,
+		`font-family: &quot;Menlo;Mono&quot;; color: red`,
+	} {
+		content := `<code style="` + style + `">x</code>`
+		if got := minifyHTML(content); got != content {
+			t.Errorf("minifyHTML(%q) = %q, want unchanged", content, got)
+		}
+	}
+}
+
+func TestMinifyHTMLDedupesStyle(t *testing.T) {
+	got := minifyHTML(`<p style="color: red; line-height: 1.75; color: #3f3f3f;">x</p>`)
+	want := `<p style="line-height: 1.75; color: #3f3f3f;">x</p>`
+	if got != want {
+		t.Errorf("minifyHTML() = %q, want %q", got, want)
+	}
+}
@@ -1,11 +1,14 @@
 package markdown
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"auto-wx-post/internal/dates"
 
 	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/html"
@@ -14,19 +17,35 @@ import (
 
 // Parser Markdown解析器
 type Parser struct {
-	htmlRenderer *html.Renderer
-	parser       *parser.Parser
+	rendererOpts  html.RendererOptions
+	extensions    parser.Extensions
+	lineBreakMode LineBreakMode
 }
 
 // Article 文章元数据
 type Article struct {
-	Title    string
-	Subtitle string
-	Date     string
-	Author   string
-	GenCover string
-	Content  string
-	Images   []string
+	Title      string
+	Subtitle   string
+	Date       string    // front matter 中的原始日期
+	DateTime   time.Time // 解析后的日期，未设置或格式无法识别时为零值
+	Updated    string    // 最后修改时间 (Hexo/Jekyll 的 updated 字段)
+	PublishAt  string    // 定时发布时间 (-schedule 模式使用)
+	Author     string
+	GenCover   string
+	ShowCover  string
+	QRCode     string            // 设为 false 时不附加文末二维码
+	Order      int               // 多图文组内的排序 (0 表示未指定)
+	TagID      string            // 草稿分组标签，仅在本地记录 (微信未提供草稿/素材分组接口)
+	Account    string            // 发布到的公众号账号 (wechat.accounts 中的名称)
+	Theme      string            // 排版主题，覆盖 publish.theme
+	Canonical  string            // 原文地址，设置后直接作为阅读原文链接
+	Captions   map[string]string // 图片文件名/URL 到图注的映射，优先于 alt
+	Posters    map[string]string // iframe 视频封面原地址到上传后地址的映射，由发布流程填充，没有映射时改用文字链接
+	Meta       map[string]string // 全部 front matter 字段，供模板引用 (如 {{.Meta.series}})
+	Tags       []string
+	Categories []string
+	Content    string
+	Images     []string
 }
 
 // NewParser 创建Markdown解析器
@@ -36,15 +55,23 @@ func NewParser() *Parser {
 	opts := html.RendererOptions{
 		Flags: htmlFlags,
 	}
-	renderer := html.NewRenderer(opts)
 
-	// 解析器扩展
-	extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.Footnotes
-	p := parser.NewWithExtensions(extensions)
+	// 解析器扩展 (显式列出，避免依赖 CommonExtensions 的隐含内容)
+	//   Tables            GFM 表格
+	//   FencedCode        ``` 代码块
+	//   Autolink          裸 URL 自动转为链接
+	//   Strikethrough     ~~删除线~~
+	//   DefinitionLists   定义列表 (术语\n: 释义)
+	//   Footnotes         [^1] 脚注
+	//   AutoHeadingIDs    标题自动生成锚点
+	extensions := parser.NoIntraEmphasis | parser.Tables | parser.FencedCode |
+		parser.Autolink | parser.Strikethrough | parser.SpaceHeadings | parser.HeadingIDs |
+		parser.BackslashLineBreak | parser.DefinitionLists | parser.MathJax |
+		parser.AutoHeadingIDs | parser.Footnotes
 
 	return &Parser{
-		htmlRenderer: renderer,
-		parser:       p,
+		rendererOpts: opts,
+		extensions:   extensions,
 	}
 }
 
@@ -60,16 +87,42 @@ func (p *Parser) ParseFile(filePath string) (*Article, error) {
 
 // Parse 解析Markdown内容
 func (p *Parser) Parse(content string) (*Article, error) {
-	article := &Article{}
-
 	// 提取元数据 (YAML front matter)
-	metadata, body := p.extractMetadata(content)
-	article.Title = p.getMetadataField(metadata, "title")
-	article.Subtitle = p.getMetadataField(metadata, "subtitle")
-	article.Date = p.getMetadataField(metadata, "date")
-	article.Author = p.getMetadataField(metadata, "author")
-	article.GenCover = p.getMetadataField(metadata, "gen_cover")
-	article.Content = body
+	fm, body := p.extractFrontMatter(content)
+
+	article := &Article{
+		Title:      fm.Title,
+		Subtitle:   fm.Subtitle,
+		Date:       fm.Date,
+		Updated:    fm.Updated,
+		PublishAt:  fm.PublishAt,
+		Author:     fm.Author,
+		GenCover:   fm.GenCover,
+		ShowCover:  fm.ShowCover,
+		QRCode:     fm.QRCode,
+		TagID:      fm.TagID,
+		Account:    fm.Account,
+		Theme:      fm.Theme,
+		Canonical:  fm.CanonicalURL,
+		Captions:   fm.Captions,
+		Tags:       fm.Tags,
+		Categories: fm.Categories,
+		Meta:       fm.metadata(),
+		Content:    body,
+	}
+	if article.Canonical == "" {
+		article.Canonical = fm.OriginalURL
+	}
+	if fm.Date != "" {
+		// 支持带时间和 / 分隔等多种格式，无法识别时只保留原始字符串
+		if t, err := dates.ParseTimestamp(fm.Date, time.Local); err == nil {
+			article.DateTime = t
+		}
+	}
+	// order 只用于多图文组排序，无效时不影响其他用途，组发布时由 ParseOrder 报错 (含文件路径)
+	if order, err := ParseOrder(fm.Order); err == nil {
+		article.Order = order
+	}
 
 	// 提取图片
 	article.Images = p.extractImages(body)
@@ -77,67 +130,33 @@ func (p *Parser) Parse(content string) (*Article, error) {
 	return article, nil
 }
 
-// ToHTML 转换为HTML
-func (p *Parser) ToHTML(content string) string {
-	md := []byte(content)
-	htmlBytes := markdown.ToHTML(md, p.parser, p.htmlRenderer)
-	return string(htmlBytes)
-}
-
-// extractMetadata 提取元数据
-func (p *Parser) extractMetadata(content string) (map[string]string, string) {
-	metadata := make(map[string]string)
-
-	// 1. 去除 BOM 头
-	content = strings.TrimPrefix(content, "\ufeff")
-
-	// 2. 统一换行符为 \n，解决 Windows CRLF 问题
-	content = strings.ReplaceAll(content, "\r\n", "\n")
-
-	// 3. 查找 YAML front matter
-	// 必须以 --- 开头
-	if !strings.HasPrefix(content, "---\n") {
-		return metadata, content
-	}
-
-	// 查找第二个 ---
-	endIndex := strings.Index(content[4:], "\n---\n")
-	if endIndex == -1 {
-		// 尝试查找文件结尾的 ---
-		if strings.HasSuffix(content, "\n---") {
-			endIndex = len(content) - 4 - 4 // 减去开头的 ---\n 和结尾的 \n---
-		} else {
-			return metadata, content
-		}
+// ParseOrder 解析 front matter 中的 order，空字符串表示未指定 (0)
+func ParseOrder(value string) (int, error) {
+	if value == "" {
+		return 0, nil
 	}
-
-	yamlContent := content[4 : 4+endIndex]
-	body := content[4+endIndex+5:] // +5 是跳过 \n---\n
-
-	// 解析元数据
-	scanner := bufio.NewScanner(strings.NewReader(yamlContent))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(line, ":") {
-			kv := strings.SplitN(line, ":", 2)
-			if len(kv) == 2 {
-				key := strings.TrimSpace(kv[0])
-				value := strings.TrimSpace(kv[1])
-				value = strings.Trim(value, `"'`)
-				metadata[key] = value
-			}
-		}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid order %q: %w", value, err)
 	}
-
-	return metadata, strings.TrimSpace(body)
+	return n, nil
 }
 
-// getMetadataField 获取元数据字段
-func (p *Parser) getMetadataField(metadata map[string]string, key string) string {
-	if val, ok := metadata[key]; ok {
-		return val
+// ToHTML 转换为HTML
+func (p *Parser) ToHTML(content string) string {
+	extensions := p.extensions
+	switch p.lineBreakMode {
+	case LineBreakHard:
+		extensions |= parser.HardLineBreak
+	case LineBreakCJK:
+		content = insertCJKBreaks(content)
 	}
-	return ""
+
+	// gomarkdown 的解析器和渲染器都带有状态 (如标题锚点去重表)，不可复用也不能并发共享，
+	// 每次转换创建新实例，保证同一内容的输出稳定且可并发调用
+	md := []byte(content)
+	htmlBytes := markdown.ToHTML(md, parser.NewWithExtensions(extensions), html.NewRenderer(p.rendererOpts))
+	return string(htmlBytes)
 }
 
 // extractImages 提取图片链接
@@ -157,6 +176,21 @@ func (p *Parser) extractImages(content string) []string {
 	return images
 }
 
+// ReplaceImages 将指定地址的图片 (整个 ![alt](url)) 替换为文本
+func (p *Parser) ReplaceImages(content string, replacements map[string]string) string {
+	if len(replacements) == 0 {
+		return content
+	}
+
+	re := regexp.MustCompile(`!\[.*?\]\((.*?)\)`)
+	return re.ReplaceAllStringFunc(content, func(match string) string {
+		if text, ok := replacements[re.FindStringSubmatch(match)[1]]; ok {
+			return text
+		}
+		return match
+	})
+}
+
 // UpdateImageURLs 更新图片URL
 func (p *Parser) UpdateImageURLs(content string, urlMap map[string]string) string {
 	result := content
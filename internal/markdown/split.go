@@ -0,0 +1,79 @@
+package markdown
+
+import (
+	"strings"
+)
+
+// SplitAtHeadings 在最高级标题处拆分正文，并按 maxLen 贪心合并相邻章节。
+// 单个章节超过 maxLen 时独立成为一部分，不会在章节内部拆分
+func SplitAtHeadings(content string, maxLen int) []string {
+	lines := strings.Split(content, "\n")
+
+	// 找出代码块外最高级的标题
+	topLevel := 0
+	inFence := false
+	for _, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+			continue
+		}
+		if level := headingLevel(line); !inFence && level > 0 && (topLevel == 0 || level < topLevel) {
+			topLevel = level
+		}
+	}
+	if topLevel == 0 {
+		return []string{content}
+	}
+
+	// 按最高级标题切分章节
+	var sections []string
+	var current []string
+	inFence = false
+	for _, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+		} else if !inFence && headingLevel(line) == topLevel && len(current) > 0 {
+			sections = append(sections, strings.Join(current, "\n"))
+			current = nil
+		}
+		current = append(current, line)
+	}
+	sections = append(sections, strings.Join(current, "\n"))
+
+	// 贪心合并
+	var parts []string
+	part := ""
+	for _, section := range sections {
+		if part != "" && len(part)+len(section) > maxLen {
+			parts = append(parts, strings.TrimSpace(part))
+			part = ""
+		}
+		if part != "" {
+			part += "\n"
+		}
+		part += section
+	}
+	if strings.TrimSpace(part) != "" {
+		parts = append(parts, strings.TrimSpace(part))
+	}
+
+	return parts
+}
+
+// headingLevel 返回 ATX 标题的级别，非标题返回 0
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && level < 6 && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level >= len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+// isFence 判断是否为代码块分隔行
+func isFence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
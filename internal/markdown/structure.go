@@ -0,0 +1,128 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StructureIssue Markdown 结构问题
+type StructureIssue struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// String 格式化为 "line N: message"
+func (i StructureIssue) String() string {
+	return fmt.Sprintf("line %d: %s", i.Line, i.Message)
+}
+
+// structureState 扫描到正文末尾时仍未闭合的结构
+type structureState struct {
+	fence     string // 未闭合的代码块分隔符
+	fenceLine int
+	mathLine  int // 未闭合的 $$ 所在行 (0 表示无)
+	comment   int // 未闭合的 <!-- 所在行 (0 表示无)
+}
+
+// CheckStructure 扫描会破坏后续渲染的结构问题：未闭合的代码块、$$ 公式块和 HTML 注释
+func CheckStructure(content string) []StructureIssue {
+	state := scanStructure(content)
+	return state.issues()
+}
+
+// FixStructure 在正文末尾补全未闭合的结构，返回修复后的内容和发现的问题
+func FixStructure(content string) (string, []StructureIssue) {
+	state := scanStructure(content)
+	issues := state.issues()
+	if len(issues) == 0 {
+		return content, nil
+	}
+
+	content = strings.TrimRight(content, "\n")
+	if state.comment > 0 {
+		content += "\n-->"
+	}
+	if state.mathLine > 0 {
+		content += "\n$$"
+	}
+	if state.fence != "" {
+		content += "\n" + state.fence
+	}
+	return content + "\n", issues
+}
+
+// scanStructure 逐行扫描，代码块内不检查其他结构
+func scanStructure(content string) structureState {
+	var state structureState
+
+	for i, line := range strings.Split(content, "\n") {
+		lineNo := i + 1
+		trimmed := strings.TrimSpace(line)
+
+		if state.fence != "" {
+			// 闭合分隔符需使用相同字符且长度不短于开头
+			if strings.HasPrefix(trimmed, state.fence) && strings.Trim(trimmed, state.fence[:1]) == "" {
+				state.fence = ""
+			}
+			continue
+		}
+
+		if state.comment > 0 {
+			if strings.Contains(line, "-->") {
+				state.comment = 0
+			}
+			continue
+		}
+
+		if fence := fenceMarker(trimmed); fence != "" {
+			state.fence = fence
+			state.fenceLine = lineNo
+			continue
+		}
+
+		if start := strings.LastIndex(line, "<!--"); start >= 0 && !strings.Contains(line[start:], "-->") {
+			state.comment = lineNo
+			continue
+		}
+
+		// 同一行内成对出现的 $$ 为行内公式
+		if strings.Count(line, "$$")%2 == 1 {
+			if state.mathLine > 0 {
+				state.mathLine = 0
+			} else {
+				state.mathLine = lineNo
+			}
+		}
+	}
+
+	return state
+}
+
+// issues 将未闭合的结构转换为问题列表
+func (s structureState) issues() []StructureIssue {
+	var issues []StructureIssue
+	if s.fence != "" {
+		issues = append(issues, StructureIssue{s.fenceLine, fmt.Sprintf("unclosed code fence %s", s.fence)})
+	}
+	if s.mathLine > 0 {
+		issues = append(issues, StructureIssue{s.mathLine, "unmatched $$ math block"})
+	}
+	if s.comment > 0 {
+		issues = append(issues, StructureIssue{s.comment, "unclosed HTML comment <!--"})
+	}
+	return issues
+}
+
+// fenceMarker 返回代码块开头的分隔符 (``` 或 ~~~ 及其长度)
+func fenceMarker(trimmed string) string {
+	for _, ch := range []string{"`", "~"} {
+		n := 0
+		for n < len(trimmed) && trimmed[n] == ch[0] {
+			n++
+		}
+		if n >= 3 {
+			return trimmed[:n]
+		}
+	}
+	return ""
+}
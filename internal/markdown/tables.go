@@ -0,0 +1,77 @@
+package markdown
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// tableStyle 获取表格元素样式，模板名即元素名，tr_alt 为隔行背景。
+// 微信编辑器会丢弃外部CSS，样式必须写在行内
+func (b *Beautifier) tableStyle(name string) string {
+	return strings.TrimSpace(b.getTemplate(name))
+}
+
+// formatTables 为表格添加行内边框、内边距和隔行背景。
+// 没有表头的表格从第一行起隔行着色，空单元格填充空格避免被微信折叠
+func (b *Beautifier) formatTables(content string) string {
+	if !strings.Contains(content, "<table") {
+		return content
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+
+	doc.Find("table").Each(func(i int, table *goquery.Selection) {
+		addStyle(table, b.tableStyle("table"))
+
+		bodyRow := 0
+		table.Find("tr").Each(func(j int, row *goquery.Selection) {
+			// 表头行不参与隔行着色
+			if row.Find("td").Length() == 0 {
+				return
+			}
+			if bodyRow%2 == 1 {
+				addStyle(row, b.tableStyle("tr_alt"))
+			}
+			bodyRow++
+		})
+
+		table.Find("th, td").Each(func(j int, cell *goquery.Selection) {
+			addStyle(cell, b.tableStyle(goquery.NodeName(cell)))
+
+			// gomarkdown 用 align 属性表示列对齐，转换为行内样式
+			if align, ok := cell.Attr("align"); ok {
+				addStyle(cell, "text-align: "+align+";")
+				cell.RemoveAttr("align")
+			}
+
+			if strings.TrimSpace(cell.Text()) == "" && cell.Children().Length() == 0 {
+				cell.SetHtml("&nbsp;")
+			}
+		})
+	})
+
+	html, err := doc.Find("body").Html()
+	if err != nil {
+		return content
+	}
+	return html
+}
+
+// addStyle 在元素已有的 style 后追加样式
+func addStyle(s *goquery.Selection, style string) {
+	if style == "" {
+		return
+	}
+	if existing, ok := s.Attr("style"); ok && strings.TrimSpace(existing) != "" {
+		existing = strings.TrimSpace(existing)
+		if !strings.HasSuffix(existing, ";") {
+			existing += ";"
+		}
+		style = existing + " " + style
+	}
+	s.SetAttr("style", style)
+}
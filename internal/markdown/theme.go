@@ -0,0 +1,110 @@
+package markdown
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultTheme 默认主题，只使用 assets 中的内置默认模板
+const DefaultTheme = "default"
+
+// themeFS 内置主题，每个主题一个目录，文件名与模板名相同
+//
+//go:embed themes
+var themeFS embed.FS
+
+// assetFS 内置默认模板，主题和外部模板目录中缺少的模板都回退到这里
+//
+//go:embed assets/*.tmpl
+var assetFS embed.FS
+
+// defaultTemplates 模板名到内置默认模板内容的映射
+var defaultTemplates = loadDefaultTemplates()
+
+// loadDefaultTemplates 读取内置默认模板
+func loadDefaultTemplates() map[string]string {
+	templates := make(map[string]string)
+	entries, err := fs.ReadDir(assetFS, "assets")
+	if err != nil {
+		panic(fmt.Sprintf("read embedded templates: %v", err))
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".tmpl")
+		if !ok {
+			continue
+		}
+		content, err := fs.ReadFile(assetFS, path.Join("assets", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("read embedded template %s: %v", entry.Name(), err))
+		}
+		templates[name] = string(content)
+	}
+	return templates
+}
+
+// Themes 返回所有可用的主题名称
+func Themes() []string {
+	names := []string{DefaultTheme}
+	entries, err := fs.ReadDir(themeFS, "themes")
+	if err != nil {
+		return names
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// loadTheme 加载内置主题的模板，主题不存在时返回错误
+func (b *Beautifier) loadTheme(theme string) error {
+	if theme == "" || theme == DefaultTheme {
+		return nil
+	}
+
+	dir := path.Join("themes", theme)
+	entries, err := fs.ReadDir(themeFS, dir)
+	if err != nil {
+		return fmt.Errorf("unknown theme %q, available: %s", theme, strings.Join(Themes(), ", "))
+	}
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".tmpl")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		content, err := fs.ReadFile(themeFS, path.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("read theme template %s: %w", entry.Name(), err)
+		}
+		b.cssTemplates[name] = string(content)
+	}
+	return nil
+}
+
+// WithTheme 返回使用另一主题的美化器副本，外部模板目录仍覆盖主题中的同名模板
+func (b *Beautifier) WithTheme(theme string) (*Beautifier, error) {
+	if theme == "" {
+		theme = DefaultTheme
+	}
+	if theme == b.theme {
+		return b, nil
+	}
+
+	clone := *b
+	clone.theme = theme
+	clone.cssTemplates = make(map[string]string)
+	if err := clone.loadTheme(theme); err != nil {
+		return nil, err
+	}
+	if err := clone.loadTemplates(b.templateDir); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
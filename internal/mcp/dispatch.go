@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Dispatch handles one JSON-RPC request and returns its response. The JSON-RPC
+// method routing is shared by the stdio and HTTP transports; notifications are
+// handled by the transports themselves since they never get a response.
+// A panic in a handler is turned into an internal error for that request
+// only, so it cannot take down a transport serving other requests.
+func (s *Server) Dispatch(ctx context.Context, req JSONRPCRequest) (resp JSONRPCResponse) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.log.Error("Panic handling MCP request", "method", req.Method, "id", req.ID, "panic", r)
+			resp = errorResponse(req.ID, -32603, "Internal error", fmt.Sprint(r))
+		}
+	}()
+
+	switch req.Method {
+	case "initialize":
+		return resultResponse(req.ID, s.initializeResult())
+	case "ping":
+		return resultResponse(req.ID, struct{}{})
+	case "tools/list":
+		return resultResponse(req.ID, ListToolsResult{Tools: s.GetTools()})
+	case "tools/call":
+		return s.dispatchCallTool(ctx, req)
+	case "resources/list":
+		resources, err := s.ListResources()
+		if err != nil {
+			return errorResponse(req.ID, -32603, "Internal error", err.Error())
+		}
+		return resultResponse(req.ID, ListResourcesResult{Resources: resources})
+	case "resources/read":
+		return s.dispatchReadResource(req)
+	case "prompts/list":
+		return resultResponse(req.ID, ListPromptsResult{Prompts: s.GetPrompts()})
+	case "prompts/get":
+		return s.dispatchGetPrompt(req)
+	default:
+		return errorResponse(req.ID, -32601, "Method not found", nil)
+	}
+}
+
+func (s *Server) initializeResult() InitializeResult {
+	return InitializeResult{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities: ServerCapabilities{
+			Tools: &ToolsServerCapability{
+				ListChanged: false,
+			},
+			Resources: &ResourcesServerCapability{},
+			Prompts:   &PromptsServerCapability{},
+		},
+		ServerInfo: ServerInfo{
+			Name:    ServerName,
+			Version: ServerVersion,
+		},
+	}
+}
+
+func (s *Server) dispatchCallTool(ctx context.Context, req JSONRPCRequest) JSONRPCResponse {
+	var params ToolCallParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return errorResponse(req.ID, -32602, "Invalid params", nil)
+	}
+
+	result, err := s.CallTool(ctx, params)
+	if ctx.Err() == context.Canceled {
+		return errorResponse(req.ID, -32800, "Request cancelled", nil)
+	}
+	if errors.Is(err, errUnknownTool) || errors.Is(err, errToolDisabled) {
+		return errorResponse(req.ID, -32602, "Invalid params", err.Error())
+	}
+	if err != nil {
+		return errorResponse(req.ID, -32603, "Internal error", err.Error())
+	}
+	return resultResponse(req.ID, result)
+}
+
+func (s *Server) dispatchReadResource(req JSONRPCRequest) JSONRPCResponse {
+	var params ReadResourceParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		return errorResponse(req.ID, -32602, "Invalid params", nil)
+	}
+
+	contents, err := s.ReadResource(params.URI)
+	if errors.Is(err, errResourceNotFound) {
+		return errorResponse(req.ID, -32002, "Resource not found", map[string]string{"uri": params.URI})
+	}
+	if err != nil {
+		return errorResponse(req.ID, -32603, "Internal error", err.Error())
+	}
+	return resultResponse(req.ID, ReadResourceResult{Contents: []ResourceContents{contents}})
+}
+
+func (s *Server) dispatchGetPrompt(req JSONRPCRequest) JSONRPCResponse {
+	var params GetPromptParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+		return errorResponse(req.ID, -32602, "Invalid params", nil)
+	}
+
+	result, err := s.GetPrompt(params.Name, params.Arguments)
+	if errors.Is(err, errPromptNotFound) {
+		return errorResponse(req.ID, -32602, "Unknown prompt", map[string]string{"name": params.Name})
+	}
+	if err != nil {
+		return errorResponse(req.ID, -32602, "Invalid params", err.Error())
+	}
+	return resultResponse(req.ID, result)
+}
+
+func resultResponse(id interface{}, result interface{}) JSONRPCResponse {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(id, -32603, "Internal error", err.Error())
+	}
+
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  resultJSON,
+	}
+}
+
+func errorResponse(id interface{}, code int, message string, data interface{}) JSONRPCResponse {
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error: &JSONRPCError{
+			Code:    code,
+			Message: message,
+			Data:    data,
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+
+	"auto-wx-post/internal/publisher"
+	"auto-wx-post/internal/wechat"
+)
+
+// ToolErrorCategory classifies a failed tool call so clients can decide how
+// to react without parsing the message
+type ToolErrorCategory string
+
+const (
+	// ErrValidation means the arguments or the article itself are invalid;
+	// retrying without changes will fail again
+	ErrValidation ToolErrorCategory = "validation"
+	// ErrNotFound means a file, record or draft does not exist
+	ErrNotFound ToolErrorCategory = "not_found"
+	// ErrUpstream means WeChat or the storage backend failed; retrying later
+	// may succeed
+	ErrUpstream ToolErrorCategory = "upstream"
+	// ErrConflict means the operation clashes with the current state, such as
+	// publishing an article that was already published
+	ErrConflict ToolErrorCategory = "conflict"
+)
+
+// ToolError is the machine-readable part of a failed tool call
+type ToolError struct {
+	Category ToolErrorCategory `json:"category"`
+	Message  string            `json:"message"`
+}
+
+// ToolErrorContent is the structuredContent of a failed tool call
+type ToolErrorContent struct {
+	Error ToolError `json:"error"`
+}
+
+var (
+	// errUnknownTool and errToolDisabled are protocol errors rather than tool
+	// failures, so they are returned as JSON-RPC errors
+	errUnknownTool  = errors.New("unknown tool")
+	errToolDisabled = errors.New("tool is disabled by server configuration")
+)
+
+// toolError builds a failed tool result carrying both the text message and
+// its category as structured content
+func toolError(category ToolErrorCategory, message string) ToolCallResult {
+	return ToolCallResult{
+		IsError: true,
+		Content: []Content{{
+			Type: "text",
+			Text: message,
+		}},
+		StructuredContent: ToolErrorContent{Error: ToolError{
+			Category: category,
+			Message:  message,
+		}},
+	}
+}
+
+// classifyError maps known error kinds onto the taxonomy and falls back to
+// the category typical for the failing operation
+func classifyError(err error, fallback ToolErrorCategory) ToolErrorCategory {
+	switch {
+	case wechat.IsRateLimited(err):
+		return ErrUpstream
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, publisher.ErrNoPublishRecord):
+		return ErrNotFound
+	case errors.Is(err, publisher.ErrInvalidDraft):
+		return ErrValidation
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrUpstream
+	default:
+		return fallback
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 )
 
 const (
@@ -18,24 +19,41 @@ const (
 	ServerVersion = "1.0.0"
 )
 
-// Handler handles MCP protocol communication via stdio
+// Handler handles MCP protocol communication over a newline-delimited
+// JSON-RPC stream, stdio by default
 type Handler struct {
-	server *Server
-	reader *bufio.Reader
-	writer *bufio.Writer
+	server  *Server
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+	writeMu sync.Mutex
+
+	// In-flight requests keyed by id, so clients can cancel them
+	inflight   map[string]context.CancelFunc
+	inflightMu sync.Mutex
+	wg         sync.WaitGroup
 }
 
-// NewHandler creates a new MCP handler
+// NewHandler creates a new MCP handler on stdin/stdout
 func NewHandler(server *Server) *Handler {
+	return NewStreamHandler(server, os.Stdin, os.Stdout)
+}
+
+// NewStreamHandler creates an MCP handler reading requests from r and
+// writing responses to w
+func NewStreamHandler(server *Server, r io.Reader, w io.Writer) *Handler {
 	return &Handler{
-		server: server,
-		reader: bufio.NewReader(os.Stdin),
-		writer: bufio.NewWriter(os.Stdout),
+		server:   server,
+		reader:   bufio.NewReader(r),
+		writer:   bufio.NewWriter(w),
+		inflight: make(map[string]context.CancelFunc),
 	}
 }
 
 // Run starts the MCP server loop
 func (h *Handler) Run(ctx context.Context) error {
+	// Let in-flight requests finish writing their responses before exiting
+	defer h.wg.Wait()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -62,104 +80,97 @@ func (h *Handler) handleRequest(ctx context.Context) error {
 	// Parse JSON-RPC request
 	var req JSONRPCRequest
 	if err := json.Unmarshal(line, &req); err != nil {
-		h.sendError(nil, -32700, "Parse error", nil)
-		return nil
+		return h.writeResponse(errorResponse(nil, -32700, "Parse error", nil))
 	}
 
-	// Handle method
-	switch req.Method {
-	case "initialize":
-		return h.handleInitialize(req)
-	case "initialized":
-		// Notification, no response needed
-		return nil
-	case "tools/list":
-		return h.handleListTools(req)
-	case "tools/call":
-		return h.handleCallTool(ctx, req)
-	default:
-		h.sendError(req.ID, -32601, "Method not found", nil)
+	// Notifications carry no id and must never be answered
+	if req.ID == nil {
+		h.handleNotification(req)
 		return nil
 	}
-}
-
-func (h *Handler) handleInitialize(req JSONRPCRequest) error {
-	result := InitializeResult{
-		ProtocolVersion: ProtocolVersion,
-		Capabilities: ServerCapabilities{
-			Tools: &ToolsServerCapability{
-				ListChanged: false,
-			},
-		},
-		ServerInfo: ServerInfo{
-			Name:    ServerName,
-			Version: ServerVersion,
-		},
-	}
 
-	return h.sendResult(req.ID, result)
+	h.dispatch(ctx, req)
+	return nil
 }
 
-func (h *Handler) handleListTools(req JSONRPCRequest) error {
-	tools := h.server.GetTools()
-	result := ListToolsResult{
-		Tools: tools,
+func (h *Handler) handleNotification(req JSONRPCRequest) {
+	switch req.Method {
+	case "initialized", "notifications/initialized":
+	case "notifications/cancelled", "$/cancelRequest":
+		var params struct {
+			RequestID interface{} `json:"requestId"`
+			ID        interface{} `json:"id"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return
+		}
+		id := params.RequestID
+		if id == nil {
+			id = params.ID
+		}
+		h.cancelRequest(id)
+	default:
+		h.server.log.Debug("Ignoring MCP notification", "method", req.Method)
 	}
-
-	return h.sendResult(req.ID, result)
 }
 
-func (h *Handler) handleCallTool(ctx context.Context, req JSONRPCRequest) error {
-	var params ToolCallParams
-	if err := json.Unmarshal(req.Params, &params); err != nil {
-		h.sendError(req.ID, -32602, "Invalid params", nil)
-		return nil
-	}
-
-	result, err := h.server.CallTool(ctx, params)
-	if err != nil {
-		h.sendError(req.ID, -32603, "Internal error", err.Error())
-		return nil
-	}
+// dispatch handles the request in its own goroutine so a slow tool call does
+// not block the requests pipelined behind it, nor the cancellations for it.
+// Responses may therefore arrive out of order; clients match them by id.
+func (h *Handler) dispatch(ctx context.Context, req JSONRPCRequest) {
+	reqCtx, cancel := context.WithCancel(ctx)
+	h.trackRequest(req.ID, cancel)
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		defer h.untrackRequest(req.ID)
+		defer cancel()
+
+		if err := h.writeResponse(h.server.Dispatch(reqCtx, req)); err != nil {
+			h.server.log.Error("Error sending response", "method", req.Method, "id", req.ID, "error", err)
+		}
+	}()
+}
 
-	return h.sendResult(req.ID, result)
+func (h *Handler) trackRequest(id interface{}, cancel context.CancelFunc) {
+	h.inflightMu.Lock()
+	defer h.inflightMu.Unlock()
+	h.inflight[fmt.Sprint(id)] = cancel
 }
 
-func (h *Handler) sendResult(id interface{}, result interface{}) error {
-	resultJSON, err := json.Marshal(result)
-	if err != nil {
-		return h.sendError(id, -32603, "Internal error", err.Error())
-	}
+func (h *Handler) untrackRequest(id interface{}) {
+	h.inflightMu.Lock()
+	defer h.inflightMu.Unlock()
+	delete(h.inflight, fmt.Sprint(id))
+}
 
-	response := JSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      id,
-		Result:  resultJSON,
+func (h *Handler) cancelRequest(id interface{}) {
+	if id == nil {
+		return
 	}
 
-	return h.writeResponse(response)
-}
+	h.inflightMu.Lock()
+	cancel, ok := h.inflight[fmt.Sprint(id)]
+	h.inflightMu.Unlock()
 
-func (h *Handler) sendError(id interface{}, code int, message string, data interface{}) error {
-	response := JSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      id,
-		Error: &JSONRPCError{
-			Code:    code,
-			Message: message,
-			Data:    data,
-		},
+	if ok {
+		h.server.log.Info("Cancelling MCP request", "id", id)
+		cancel()
 	}
-
-	return h.writeResponse(response)
 }
 
+// writeResponse marshals outside the lock and writes the whole line under it,
+// so concurrent responses never interleave on the shared writer
 func (h *Handler) writeResponse(response JSONRPCResponse) error {
 	data, err := json.Marshal(response)
 	if err != nil {
 		return fmt.Errorf("marshal response: %w", err)
 	}
 
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
 	if _, err := h.writer.Write(data); err != nil {
 		return fmt.Errorf("write response: %w", err)
 	}
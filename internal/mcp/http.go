@@ -0,0 +1,149 @@
+package mcp
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxHTTPMessageSize limits the body of one POST to the MCP endpoint
+const maxHTTPMessageSize = 4 << 20
+
+// HTTPTransport serves MCP over the Streamable HTTP transport. Each POST
+// carries one JSON-RPC message or a batch and is answered with a plain JSON
+// body; the server never opens an SSE stream of its own.
+type HTTPTransport struct {
+	server *Server
+	keys   [][]byte
+}
+
+// NewHTTPTransport creates an HTTP transport. When apiKeys is non-empty,
+// requests must send one of them as a Bearer token.
+func NewHTTPTransport(server *Server, apiKeys []string) *HTTPTransport {
+	t := &HTTPTransport{server: server}
+	for _, k := range apiKeys {
+		if k != "" {
+			t.keys = append(t.keys, []byte(k))
+		}
+	}
+	return t
+}
+
+// AuthEnabled reports whether requests must carry an API key
+func (t *HTTPTransport) AuthEnabled() bool {
+	return len(t.keys) > 0
+}
+
+// ListenAndServe serves the MCP endpoint at /mcp on addr
+func (t *HTTPTransport) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", t)
+	return http.ListenAndServe(addr, mux)
+}
+
+// ServeHTTP implements http.Handler
+func (t *HTTPTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !t.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// GET would open a server-initiated SSE stream, which this server never
+	// sends; there are no sessions to DELETE either
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPMessageSize+1))
+	if err != nil {
+		http.Error(w, "read body failed", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxHTTPMessageSize {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	batch := len(body) > 0 && body[0] == '['
+
+	var requests []JSONRPCRequest
+	if batch {
+		err = json.Unmarshal(body, &requests)
+	} else {
+		var req JSONRPCRequest
+		err = json.Unmarshal(body, &req)
+		requests = []JSONRPCRequest{req}
+	}
+	if err != nil {
+		writeJSON(w, errorResponse(nil, -32700, "Parse error", nil))
+		return
+	}
+
+	// Requests in a batch run concurrently and keep their order in the
+	// response. The request context is cancelled when the client
+	// disconnects, which also cancels any running tool call.
+	results := make([]*JSONRPCResponse, len(requests))
+	var wg sync.WaitGroup
+	for i, req := range requests {
+		if req.ID == nil {
+			t.server.log.Debug("Ignoring MCP notification", "method", req.Method)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, req JSONRPCRequest) {
+			defer wg.Done()
+			resp := t.server.Dispatch(r.Context(), req)
+			results[i] = &resp
+		}(i, req)
+	}
+	wg.Wait()
+
+	var responses []JSONRPCResponse
+	for _, resp := range results {
+		if resp != nil {
+			responses = append(responses, *resp)
+		}
+	}
+
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if batch {
+		writeJSON(w, responses)
+		return
+	}
+	writeJSON(w, responses[0])
+}
+
+// authorized checks the Bearer token against every key in constant time
+func (t *HTTPTransport) authorized(r *http.Request) bool {
+	if len(t.keys) == 0 {
+		return true
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	matched := 0
+	for _, k := range t.keys {
+		matched |= subtle.ConstantTimeCompare(k, []byte(token))
+	}
+	return matched == 1
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "encode response failed", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"auto-wx-post/internal/dates"
+	"auto-wx-post/internal/wechat"
+)
+
+// errPromptNotFound is returned for unknown prompt names
+var errPromptNotFound = errors.New("prompt not found")
+
+// GetPrompts returns the built-in prompt templates
+func (s *Server) GetPrompts() []Prompt {
+	return []Prompt{
+		{
+			Name:        "publish_today",
+			Description: "Review the unpublished articles of a day and publish the ones that are ready",
+			Arguments: []PromptArgument{{
+				Name:        "date",
+				Description: "Day to review: YYYY-MM-DD or a relative expression like today or -1d (default: today)",
+			}},
+		},
+		{
+			Name:        "rewrite_subtitle",
+			Description: "Rewrite an article's subtitle into a WeChat digest",
+			Arguments: []PromptArgument{{
+				Name:        "file_path",
+				Description: "Markdown file of the article",
+				Required:    true,
+			}},
+		},
+	}
+}
+
+// GetPrompt fills in a prompt template with the given arguments
+func (s *Server) GetPrompt(name string, args map[string]string) (GetPromptResult, error) {
+	switch name {
+	case "publish_today":
+		return s.publishTodayPrompt(args)
+	case "rewrite_subtitle":
+		return s.rewriteSubtitlePrompt(args)
+	default:
+		return GetPromptResult{}, errPromptNotFound
+	}
+}
+
+func (s *Server) publishTodayPrompt(args map[string]string) (GetPromptResult, error) {
+	expr := args["date"]
+	if expr == "" {
+		expr = "today"
+	}
+	day, err := dates.Resolve(expr, time.Now())
+	if err != nil {
+		return GetPromptResult{}, fmt.Errorf("invalid date: %w", err)
+	}
+	date := day.Format("2006-01-02")
+
+	text := fmt.Sprintf(`Please review and publish my WeChat articles for %s.
+
+1. Call list_articles with start_date and end_date set to %s to find the unpublished articles.
+2. For each article, call parse_article and preview_html and check the title, subtitle, images and formatting.
+3. Summarize any problems you find, then call publish_article for the articles that are ready.
+4. Report the media_id of every draft created and list the articles you skipped and why.`, date, date)
+
+	return GetPromptResult{
+		Description: fmt.Sprintf("Review and publish the articles of %s", date),
+		Messages: []PromptMessage{{
+			Role:    "user",
+			Content: Content{Type: "text", Text: text},
+		}},
+	}, nil
+}
+
+func (s *Server) rewriteSubtitlePrompt(args map[string]string) (GetPromptResult, error) {
+	filePath := args["file_path"]
+	if filePath == "" {
+		return GetPromptResult{}, fmt.Errorf("file_path is required")
+	}
+
+	article, err := s.mdParser.ParseFile(filePath)
+	if err != nil {
+		return GetPromptResult{}, fmt.Errorf("parse article: %w", err)
+	}
+
+	text := fmt.Sprintf(`Rewrite the subtitle of this article for WeChat. The subtitle is used as the digest shown under the title in the chat list, so it must be at most %d characters, make readers want to open the article, and match its tone. Suggest three options and recommend one; I will put it in the front matter subtitle field.
+
+Title: %s
+Current subtitle: %s
+
+%s`, wechat.MaxDigestLength, article.Title, article.Subtitle, article.Content)
+
+	return GetPromptResult{
+		Description: fmt.Sprintf("Rewrite the subtitle of %s", article.Title),
+		Messages: []PromptMessage{{
+			Role:    "user",
+			Content: Content{Type: "text", Text: text},
+		}},
+	}, nil
+}
@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"auto-wx-post/internal/catalog"
+)
+
+// errResourceNotFound is returned for URIs outside blog.source_path or
+// files that do not exist
+var errResourceNotFound = errors.New("resource not found")
+
+// ListResources lists the Markdown files under blog.source_path as file://
+// resources, newest first
+func (s *Server) ListResources() ([]Resource, error) {
+	articles, err := s.finder.Find(catalog.Filter{ShowPublished: true})
+	if err != nil {
+		return nil, fmt.Errorf("find articles: %w", err)
+	}
+
+	resources := make([]Resource, 0, len(articles))
+	for _, article := range articles {
+		absPath, err := filepath.Abs(article.Path)
+		if err != nil {
+			continue
+		}
+		description := article.Date
+		if article.Subtitle != "" {
+			description = strings.TrimSpace(description + " " + article.Subtitle)
+		}
+		resources = append(resources, Resource{
+			URI:         fileURI(absPath),
+			Name:        article.Title,
+			Description: description,
+			MimeType:    "text/markdown",
+		})
+	}
+	return resources, nil
+}
+
+// ReadResource returns the raw text of a Markdown file. Only files under
+// blog.source_path can be read.
+func (s *Server) ReadResource(uri string) (ResourceContents, error) {
+	path, err := s.resourcePath(uri)
+	if err != nil {
+		return ResourceContents{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ResourceContents{}, errResourceNotFound
+	}
+	if err != nil {
+		return ResourceContents{}, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	return ResourceContents{
+		URI:      uri,
+		MimeType: "text/markdown",
+		Text:     string(data),
+	}, nil
+}
+
+// resourcePath maps a file:// URI to a path, rejecting anything outside the
+// source directory or that is not a Markdown file
+func (s *Server) resourcePath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", errResourceNotFound
+	}
+
+	root, err := filepath.Abs(s.cfg.Blog.SourcePath)
+	if err != nil {
+		return "", fmt.Errorf("resolve source path: %w", err)
+	}
+	path := filepath.Clean(filepath.FromSlash(u.Path))
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.Ext(path) != ".md" {
+		return "", errResourceNotFound
+	}
+	return path, nil
+}
+
+// fileURI builds a file:// URI for an absolute path
+func fileURI(absPath string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(absPath)}).String()
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"auto-wx-post/internal/cache"
+	"auto-wx-post/internal/catalog"
 	"auto-wx-post/internal/config"
+	"auto-wx-post/internal/dates"
 	"auto-wx-post/internal/logger"
 	"auto-wx-post/internal/markdown"
 	"auto-wx-post/internal/media"
@@ -23,7 +26,9 @@ type Server struct {
 	cacheManager *cache.Manager
 	mediaManager *media.Manager
 	publisher    *publisher.Publisher
+	resolve      publisher.Resolver // picks the publisher by the article's account, nil uses publisher
 	mdParser     *markdown.Parser
+	finder       *catalog.Finder
 	log          *logger.Logger
 }
 
@@ -36,19 +41,71 @@ func NewServer(
 	pub *publisher.Publisher,
 	log *logger.Logger,
 ) *Server {
+	mdParser := markdown.NewParser()
 	return &Server{
 		cfg:          cfg,
 		wechatClient: wechatClient,
 		cacheManager: cacheManager,
 		mediaManager: mediaManager,
 		publisher:    pub,
-		mdParser:     markdown.NewParser(),
+		mdParser:     mdParser,
+		finder:       catalog.NewFinder(cfg.Blog.SourcePath, mdParser, cacheManager, log.Logger),
 		log:          log,
 	}
 }
 
-// GetTools returns the list of available tools
+// SetPublisherResolver routes publishing tools to the publisher of each
+// article's account (front matter account) instead of the one passed to NewServer
+func (s *Server) SetPublisherResolver(resolve publisher.Resolver) {
+	s.resolve = resolve
+}
+
+// publisherFor returns the publisher for the account filePath belongs to
+func (s *Server) publisherFor(filePath string) (*publisher.Publisher, error) {
+	if s.resolve == nil {
+		return s.publisher, nil
+	}
+	return s.resolve(filePath)
+}
+
+// readOnlyTools are tools that never modify WeChat or local state
+var readOnlyTools = map[string]bool{
+	"list_articles":     true,
+	"parse_article":     true,
+	"get_cache_status":  true,
+	"get_cache_entries": true,
+	"preview_html":      true,
+}
+
+// GetTools returns the list of enabled tools
 func (s *Server) GetTools() []Tool {
+	tools := []Tool{} // never nil, so an empty list encodes as [] rather than null
+	for _, tool := range s.allTools() {
+		if s.isToolEnabled(tool.Name) {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// isToolEnabled reports whether a tool is allowed by the mcp config
+func (s *Server) isToolEnabled(name string) bool {
+	if s.cfg.MCP.ReadOnly && !readOnlyTools[name] {
+		return false
+	}
+	if len(s.cfg.MCP.EnabledTools) == 0 {
+		return true
+	}
+	for _, enabled := range s.cfg.MCP.EnabledTools {
+		if enabled == name {
+			return true
+		}
+	}
+	return false
+}
+
+// allTools returns every tool the server implements
+func (s *Server) allTools() []Tool {
 	return []Tool{
 		{
 			Name:        "list_articles",
@@ -58,16 +115,24 @@ func (s *Server) GetTools() []Tool {
 				Properties: map[string]Property{
 					"start_date": {
 						Type:        "string",
-						Description: "开始日期 (YYYY-MM-DD 格式)，留空使用配置的 days_before",
+						Description: "开始日期 (YYYY-MM-DD 格式，或 today、yesterday、-7d 等相对表达式)，留空不限制",
 					},
 					"end_date": {
 						Type:        "string",
-						Description: "结束日期 (YYYY-MM-DD 格式)，留空使用配置的 days_after",
+						Description: "结束日期 (YYYY-MM-DD 格式，或 today、+3d 等相对表达式)，留空不限制",
 					},
 					"show_published": {
 						Type:        "boolean",
 						Description: "是否显示已发布的文章 (默认: false)",
 					},
+					"page": {
+						Type:        "integer",
+						Description: "页码，从 1 开始 (默认: 1)",
+					},
+					"page_size": {
+						Type:        "integer",
+						Description: "每页数量 (默认: 50，最大 500)",
+					},
 				},
 			},
 		},
@@ -85,6 +150,46 @@ func (s *Server) GetTools() []Tool {
 				Required: []string{"file_path"},
 			},
 		},
+		{
+			Name:        "update_draft",
+			Description: "重新渲染文章并原地更新已有的公众号草稿，避免反复修改时产生重复草稿。省略 media_id 时更新该文件上次发布的草稿。",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]Property{
+					"file_path": {
+						Type:        "string",
+						Description: "Markdown 文件路径",
+					},
+					"media_id": {
+						Type:        "string",
+						Description: "要更新的草稿 media_id (默认: 该文件发布记录中的草稿)",
+					},
+					"index": {
+						Type:        "integer",
+						Description: "文章在多图文草稿中的位置，0 为头条 (默认: 发布记录中的位置，没有时为 0)",
+					},
+				},
+				Required: []string{"file_path"},
+			},
+		},
+		{
+			Name:        "preview_html",
+			Description: "渲染文章并返回将要发布的最终 HTML (与发布使用相同的主题和样式)，用于检查排版。图片保留原地址，不上传。",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]Property{
+					"file_path": {
+						Type:        "string",
+						Description: "Markdown 文件路径",
+					},
+					"max_length": {
+						Type:        "integer",
+						Description: "返回的 HTML 最大字符数，超出部分截断 (默认: 不截断)",
+					},
+				},
+				Required: []string{"file_path"},
+			},
+		},
 		{
 			Name:        "upload_image",
 			Description: "上传单张图片到微信公众号，返回图片的 media_id 和 URL。支持本地文件路径或远程 URL。",
@@ -125,6 +230,33 @@ func (s *Server) GetTools() []Tool {
 				Properties: map[string]Property{},
 			},
 		},
+		{
+			Name:        "get_cache_entries",
+			Description: "列出缓存条目，包括已处理文件的路径、草稿 media_id 和发布时间，用于排查文章为何被跳过。",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]Property{
+					"kind": {
+						Type:        "string",
+						Description: "只列出指定类型: file (已处理文件)、publish (按路径的发布记录) 或 image (图片上传)，留空表示全部",
+					},
+				},
+			},
+		},
+		{
+			Name:        "forget_article",
+			Description: "删除一篇文章的全部发布记录，下次运行时将重新发布该文章，其他文章的记录不受影响。",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]Property{
+					"file_path": {
+						Type:        "string",
+						Description: "要忘记的 Markdown 文件路径",
+					},
+				},
+				Required: []string{"file_path"},
+			},
+		},
 		{
 			Name:        "clear_cache",
 			Description: "清空缓存。警告：这将清除所有已发布文章的记录，可能导致重复发布。",
@@ -138,68 +270,91 @@ func (s *Server) GetTools() []Tool {
 
 // CallTool executes a tool with the given parameters
 func (s *Server) CallTool(ctx context.Context, params ToolCallParams) (ToolCallResult, error) {
-	s.log.Info("MCP tool called", "tool", params.Name)
+	ctx = logger.EnsureRequestID(ctx)
+	s.log.InfoContext(ctx, "MCP tool called", "tool", params.Name)
+
+	if !s.isToolEnabled(params.Name) {
+		return ToolCallResult{}, fmt.Errorf("%w: %s", errToolDisabled, params.Name)
+	}
 
 	switch params.Name {
 	case "list_articles":
 		return s.handleListArticles(ctx, params.Arguments)
 	case "parse_article":
 		return s.handleParseArticle(ctx, params.Arguments)
+	case "update_draft":
+		return s.handleUpdateDraft(ctx, params.Arguments)
+	case "preview_html":
+		return s.handlePreviewHTML(ctx, params.Arguments)
 	case "upload_image":
 		return s.handleUploadImage(ctx, params.Arguments)
 	case "publish_article":
 		return s.handlePublishArticle(ctx, params.Arguments)
 	case "get_cache_status":
 		return s.handleGetCacheStatus(ctx, params.Arguments)
+	case "get_cache_entries":
+		return s.handleGetCacheEntries(ctx, params.Arguments)
+	case "forget_article":
+		return s.handleForgetArticle(ctx, params.Arguments)
 	case "clear_cache":
 		return s.handleClearCache(ctx, params.Arguments)
 	default:
-		return ToolCallResult{
-			IsError: true,
-			Content: []Content{{
-				Type: "text",
-				Text: fmt.Sprintf("Unknown tool: %s", params.Name),
-			}},
-		}, nil
+		return ToolCallResult{}, fmt.Errorf("%w: %s", errUnknownTool, params.Name)
 	}
 }
 
 func (s *Server) handleListArticles(ctx context.Context, args map[string]interface{}) (ToolCallResult, error) {
 	// Parse arguments
-	var startDate, endDate string
+	var startDate, endDate time.Time
 	showPublished := false
 
+	now := time.Now()
 	if val, ok := args["start_date"].(string); ok && val != "" {
-		startDate = val
+		resolved, err := dates.Resolve(val, now)
+		if err != nil {
+			return toolError(ErrValidation, fmt.Sprintf("Invalid start_date: %v", err)), nil
+		}
+		startDate = resolved
 	}
 	if val, ok := args["end_date"].(string); ok && val != "" {
-		endDate = val
+		resolved, err := dates.Resolve(val, now)
+		if err != nil {
+			return toolError(ErrValidation, fmt.Sprintf("Invalid end_date: %v", err)), nil
+		}
+		endDate = resolved
 	}
 	if val, ok := args["show_published"].(bool); ok {
 		showPublished = val
 	}
+	// JSON numbers decode as float64
+	pageNum, pageSize := 1, 0
+	if val, ok := args["page"].(float64); ok {
+		pageNum = int(val)
+	}
+	if val, ok := args["page_size"].(float64); ok {
+		pageSize = int(val)
+	}
 
-	// Find articles
-	articles, err := s.findArticles(startDate, endDate, showPublished)
+	// Find articles, newest first
+	page, err := s.finder.FindPage(catalog.Filter{
+		StartDate:     startDate,
+		EndDate:       endDate,
+		ShowPublished: showPublished,
+	}, pageNum, pageSize)
 	if err != nil {
-		return ToolCallResult{
-			IsError: true,
-			Content: []Content{{
-				Type: "text",
-				Text: fmt.Sprintf("Failed to find articles: %v", err),
-			}},
-		}, nil
+		return ToolCallResult{}, fmt.Errorf("find articles: %w", err)
 	}
 
 	// Format result
-	result := fmt.Sprintf("Found %d article(s):\n\n", len(articles))
-	for i, article := range articles {
+	offset := (page.Page - 1) * page.PageSize
+	result := fmt.Sprintf("Found %d article(s), page %d (page size %d):\n\n", page.Total, page.Page, page.PageSize)
+	for i, article := range page.Articles {
 		status := "未发布"
 		if article.Published {
 			status = "已发布"
 		}
 		result += fmt.Sprintf("%d. %s\n   Path: %s\n   Status: %s\n\n",
-			i+1, article.Title, article.Path, status)
+			offset+i+1, article.Title, article.Path, status)
 	}
 
 	return ToolCallResult{
@@ -213,25 +368,13 @@ func (s *Server) handleListArticles(ctx context.Context, args map[string]interfa
 func (s *Server) handleParseArticle(ctx context.Context, args map[string]interface{}) (ToolCallResult, error) {
 	filePath, ok := args["file_path"].(string)
 	if !ok || filePath == "" {
-		return ToolCallResult{
-			IsError: true,
-			Content: []Content{{
-				Type: "text",
-				Text: "file_path is required",
-			}},
-		}, nil
+		return toolError(ErrValidation, "file_path is required"), nil
 	}
 
 	// Parse article
 	article, err := s.mdParser.ParseFile(filePath)
 	if err != nil {
-		return ToolCallResult{
-			IsError: true,
-			Content: []Content{{
-				Type: "text",
-				Text: fmt.Sprintf("Failed to parse article: %v", err),
-			}},
-		}, nil
+		return toolError(classifyError(err, ErrValidation), fmt.Sprintf("Failed to parse article: %v", err)), nil
 	}
 
 	// Format result
@@ -266,25 +409,13 @@ Content Preview (first 500 chars):
 func (s *Server) handleUploadImage(ctx context.Context, args map[string]interface{}) (ToolCallResult, error) {
 	imagePath, ok := args["image_path"].(string)
 	if !ok || imagePath == "" {
-		return ToolCallResult{
-			IsError: true,
-			Content: []Content{{
-				Type: "text",
-				Text: "image_path is required",
-			}},
-		}, nil
+		return toolError(ErrValidation, "image_path is required"), nil
 	}
 
 	// Upload image
 	imageInfo, err := s.mediaManager.UploadImage(ctx, imagePath)
 	if err != nil {
-		return ToolCallResult{
-			IsError: true,
-			Content: []Content{{
-				Type: "text",
-				Text: fmt.Sprintf("Failed to upload image: %v", err),
-			}},
-		}, nil
+		return toolError(classifyError(err, ErrUpstream), fmt.Sprintf("Failed to upload image: %v", err)), nil
 	}
 
 	result := fmt.Sprintf(`Image uploaded successfully:
@@ -306,13 +437,7 @@ URL: %s
 func (s *Server) handlePublishArticle(ctx context.Context, args map[string]interface{}) (ToolCallResult, error) {
 	filePath, ok := args["file_path"].(string)
 	if !ok || filePath == "" {
-		return ToolCallResult{
-			IsError: true,
-			Content: []Content{{
-				Type: "text",
-				Text: "file_path is required",
-			}},
-		}, nil
+		return toolError(ErrValidation, "file_path is required"), nil
 	}
 
 	force := false
@@ -320,127 +445,220 @@ func (s *Server) handlePublishArticle(ctx context.Context, args map[string]inter
 		force = val
 	}
 
+	pub, err := s.publisherFor(filePath)
+	if err != nil {
+		return toolError(ErrValidation, err.Error()), nil
+	}
+
 	// Check if already published
 	if !force {
-		published, _ := s.cacheManager.IsFileProcessed(filePath)
+		published, _ := pub.IsPublished(filePath)
 		if published {
-			return ToolCallResult{
-				Content: []Content{{
-					Type: "text",
-					Text: "Article already published. Use force=true to republish.",
-				}},
-			}, nil
+			return toolError(ErrConflict, "Article already published. Use force=true to republish."), nil
 		}
 	}
 
-	// Publish article
-	err := s.publisher.PublishArticle(ctx, filePath)
+	// Publish article; force also bypasses the unchanged-content check
+	publish := pub.PublishArticle
+	if force {
+		publish = pub.RepublishArticle
+	}
+	publishResult, err := publish(ctx, filePath)
 	if err != nil {
-		return ToolCallResult{
-			IsError: true,
-			Content: []Content{{
-				Type: "text",
-				Text: fmt.Sprintf("Failed to publish article: %v", err),
-			}},
-		}, nil
+		return toolError(classifyError(err, ErrUpstream), fmt.Sprintf("Failed to publish article: %v", err)), nil
 	}
 
 	return ToolCallResult{
 		Content: []Content{{
 			Type: "text",
-			Text: fmt.Sprintf("Article published successfully: %s", filePath),
+			Text: formatPublishResult(publishResult),
 		}},
 	}, nil
 }
 
-func (s *Server) handleGetCacheStatus(ctx context.Context, args map[string]interface{}) (ToolCallResult, error) {
-	size := s.cacheManager.Size()
-	result := fmt.Sprintf("Cache contains %d processed article(s).\n", size)
+// formatPublishResult formats a publish result as text
+func formatPublishResult(r *publisher.PublishResult) string {
+	if r.CacheHit {
+		return fmt.Sprintf("Article already published, skipped: %s\n", r.FilePath)
+	}
+	if r.Unchanged {
+		return fmt.Sprintf("Article draft unchanged since last publish (media_id: %s), skipped: %s\n", r.MediaID, r.FilePath)
+	}
+
+	result := fmt.Sprintf(`Article published successfully:
+File: %s
+Title: %s
+Media ID: %s
+Source URL: %s
+Images Uploaded: %d
+`,
+		r.FilePath,
+		r.Title,
+		r.MediaID,
+		r.SourceURL,
+		r.ImagesUploaded,
+	)
+	if r.PreviewURL != "" {
+		result += fmt.Sprintf("Preview URL: %s\n", r.PreviewURL)
+	}
+	if len(r.Warnings) > 0 {
+		result += "\nWarnings:\n"
+		for _, warning := range r.Warnings {
+			result += fmt.Sprintf("- %s\n", warning)
+		}
+	}
+	return result
+}
+
+func (s *Server) handleUpdateDraft(ctx context.Context, args map[string]interface{}) (ToolCallResult, error) {
+	filePath, ok := args["file_path"].(string)
+	if !ok || filePath == "" {
+		return toolError(ErrValidation, "file_path is required"), nil
+	}
+	mediaID, _ := args["media_id"].(string)
+	index := -1
+	if val, ok := args["index"].(float64); ok {
+		index = int(val)
+	}
+
+	pub, err := s.publisherFor(filePath)
+	if err != nil {
+		return toolError(ErrValidation, err.Error()), nil
+	}
+
+	result, err := pub.UpdateDraft(ctx, filePath, mediaID, index)
+	if err != nil {
+		return toolError(classifyError(err, ErrUpstream), fmt.Sprintf("Failed to update draft: %v", err)), nil
+	}
+
+	text := fmt.Sprintf("Draft updated.\nTitle: %s\nMedia ID: %s\n", result.Title, result.MediaID)
+	if result.DryRun {
+		text = fmt.Sprintf("Dry run: draft payload is valid, draft %s not changed.\nTitle: %s\n", result.MediaID, result.Title)
+	}
+	for _, warning := range result.Warnings {
+		text += fmt.Sprintf("Warning: %s\n", warning)
+	}
 
 	return ToolCallResult{
 		Content: []Content{{
 			Type: "text",
-			Text: result,
+			Text: text,
 		}},
 	}, nil
 }
 
-func (s *Server) handleClearCache(ctx context.Context, args map[string]interface{}) (ToolCallResult, error) {
-	err := s.cacheManager.Clear()
+func (s *Server) handlePreviewHTML(ctx context.Context, args map[string]interface{}) (ToolCallResult, error) {
+	filePath, ok := args["file_path"].(string)
+	if !ok || filePath == "" {
+		return toolError(ErrValidation, "file_path is required"), nil
+	}
+
+	pub, err := s.publisherFor(filePath)
 	if err != nil {
-		return ToolCallResult{
-			IsError: true,
-			Content: []Content{{
-				Type: "text",
-				Text: fmt.Sprintf("Failed to clear cache: %v", err),
-			}},
-		}, nil
+		return toolError(ErrValidation, err.Error()), nil
 	}
 
+	html, article, warnings, err := pub.RenderHTML(ctx, filePath)
+	if err != nil {
+		return toolError(classifyError(err, ErrValidation), fmt.Sprintf("Failed to render article: %v", err)), nil
+	}
+
+	total := utf8.RuneCountInString(html)
+	if val, ok := args["max_length"].(float64); ok && val > 0 && int(val) < total {
+		html = string([]rune(html)[:int(val)]) + fmt.Sprintf("\n<!-- truncated, %d of %d characters shown -->", int(val), total)
+	}
+
+	result := fmt.Sprintf("Rendered HTML for %s (%d characters):\n", article.Title, total)
+	for _, warning := range warnings {
+		result += fmt.Sprintf("Warning: %s\n", warning)
+	}
+	result += "\n" + html
+
 	return ToolCallResult{
 		Content: []Content{{
 			Type: "text",
-			Text: "Cache cleared successfully.",
+			Text: result,
 		}},
 	}, nil
 }
 
-// ArticleInfo holds information about an article
-type ArticleInfo struct {
-	Path      string
-	Title     string
-	Published bool
-}
+func (s *Server) handleGetCacheStatus(ctx context.Context, args map[string]interface{}) (ToolCallResult, error) {
+	size := s.cacheManager.Size()
+	result := fmt.Sprintf("Cache contains %d processed article(s).\n", size)
 
-func (s *Server) findArticles(startDate, endDate string, showPublished bool) ([]ArticleInfo, error) {
-	var articles []ArticleInfo
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: result,
+		}},
+	}, nil
+}
 
-	sourcePath := s.cfg.Blog.SourcePath
-	err := filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+func (s *Server) handleGetCacheEntries(ctx context.Context, args map[string]interface{}) (ToolCallResult, error) {
+	kind, _ := args["kind"].(string)
 
-		if info.IsDir() || filepath.Ext(path) != ".md" {
-			return nil
+	var lines []string
+	for _, entry := range s.cacheManager.Entries() {
+		if kind != "" && entry.Kind != kind {
+			continue
 		}
-
-		// Parse article to get metadata
-		article, err := s.mdParser.ParseFile(path)
-		if err != nil {
-			s.log.Warn("Failed to parse article", "path", path, "error", err)
-			return nil
+		line := fmt.Sprintf("- [%s] %s\n  Time: %s", entry.Kind, entry.Key, entry.Timestamp.Format(time.RFC3339))
+		if entry.FilePath != "" {
+			line += "\n  Path: " + entry.FilePath
 		}
-
-		// Check date range if specified
-		if startDate != "" && article.Date < startDate {
-			return nil
+		if entry.MediaID != "" {
+			line += "\n  Media ID: " + entry.MediaID
 		}
-		if endDate != "" && article.Date > endDate {
-			return nil
+		if entry.URL != "" {
+			line += "\n  URL: " + entry.URL
 		}
+		lines = append(lines, line)
+	}
 
-		// Check published status
-		published, _ := s.cacheManager.IsFileProcessed(path)
-		if !showPublished && published {
-			return nil
-		}
+	result := fmt.Sprintf("Found %d cache entries (newest first):\n\n%s\n", len(lines), strings.Join(lines, "\n"))
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: result,
+		}},
+	}, nil
+}
 
-		title := article.Title
-		if title == "" {
-			title = filepath.Base(path)
-		}
+func (s *Server) handleForgetArticle(ctx context.Context, args map[string]interface{}) (ToolCallResult, error) {
+	filePath, ok := args["file_path"].(string)
+	if !ok || filePath == "" {
+		return toolError(ErrValidation, "file_path is required"), nil
+	}
 
-		articles = append(articles, ArticleInfo{
-			Path:      path,
-			Title:     title,
-			Published: published,
-		})
+	removed, err := s.cacheManager.DeleteByFilePath(filePath)
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("delete cache entries: %w", err)
+	}
 
-		return nil
-	})
+	text := fmt.Sprintf("Removed %d cache entries for %s. It will be published again on the next run.", removed, filePath)
+	if removed == 0 {
+		text = fmt.Sprintf("No cache entries found for %s.", filePath)
+	}
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: text,
+		}},
+	}, nil
+}
 
-	return articles, err
+func (s *Server) handleClearCache(ctx context.Context, args map[string]interface{}) (ToolCallResult, error) {
+	err := s.cacheManager.Clear()
+	if err != nil {
+		return ToolCallResult{}, fmt.Errorf("clear cache: %w", err)
+	}
+
+	return ToolCallResult{
+		Content: []Content{{
+			Type: "text",
+			Text: "Cache cleared successfully.",
+		}},
+	}, nil
 }
 
 func truncateString(s string, maxLen int) string {
@@ -60,7 +60,9 @@ type InitializeResult struct {
 
 // ServerCapabilities represents server capabilities
 type ServerCapabilities struct {
-	Tools *ToolsServerCapability `json:"tools,omitempty"`
+	Tools     *ToolsServerCapability     `json:"tools,omitempty"`
+	Resources *ResourcesServerCapability `json:"resources,omitempty"`
+	Prompts   *PromptsServerCapability   `json:"prompts,omitempty"`
 }
 
 // ToolsServerCapability represents server tool capabilities
@@ -68,6 +70,17 @@ type ToolsServerCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
 }
 
+// ResourcesServerCapability represents server resource capabilities
+type ResourcesServerCapability struct {
+	Subscribe   bool `json:"subscribe,omitempty"`
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// PromptsServerCapability represents server prompt capabilities
+type PromptsServerCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
 // ServerInfo represents information about the server
 type ServerInfo struct {
 	Name    string `json:"name"`
@@ -103,8 +116,9 @@ type ToolCallParams struct {
 
 // ToolCallResult represents the result of a tool call
 type ToolCallResult struct {
-	Content []Content `json:"content"`
-	IsError bool      `json:"isError,omitempty"`
+	Content           []Content   `json:"content"`
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
 }
 
 // Content represents tool output content
@@ -117,3 +131,70 @@ type Content struct {
 type ListToolsResult struct {
 	Tools []Tool `json:"tools"`
 }
+
+// Resource represents an MCP resource
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ListResourcesResult represents the result of listing resources
+type ListResourcesResult struct {
+	Resources []Resource `json:"resources"`
+}
+
+// ReadResourceParams represents parameters for reading a resource
+type ReadResourceParams struct {
+	URI string `json:"uri"`
+}
+
+// ResourceContents represents the text contents of a resource
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// ReadResourceResult represents the result of reading a resource
+type ReadResourceResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+// Prompt represents an MCP prompt template
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument represents an argument of a prompt template
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// ListPromptsResult represents the result of listing prompts
+type ListPromptsResult struct {
+	Prompts []Prompt `json:"prompts"`
+}
+
+// GetPromptParams represents parameters for getting a prompt
+type GetPromptParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// PromptMessage represents a message of a filled-in prompt
+type PromptMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// GetPromptResult represents the result of getting a prompt
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
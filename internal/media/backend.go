@@ -0,0 +1,57 @@
+package media
+
+import (
+	"context"
+	"fmt"
+
+	"auto-wx-post/internal/config"
+	"auto-wx-post/internal/wechat"
+)
+
+// ImageBackend 图片存储后端
+type ImageBackend interface {
+	// Name 后端名称，用于区分缓存键
+	Name() string
+	// Upload 上传本地图片，返回可在正文中引用的地址
+	Upload(ctx context.Context, localPath string) (*ImageInfo, error)
+}
+
+// WeChatBackend 上传为微信永久素材
+type WeChatBackend struct {
+	client *wechat.Client
+}
+
+// NewWeChatBackend 创建微信素材后端
+func NewWeChatBackend(client *wechat.Client) *WeChatBackend {
+	return &WeChatBackend{client: client}
+}
+
+// Name 后端名称
+func (b *WeChatBackend) Name() string {
+	return "wechat"
+}
+
+// Upload 上传到微信永久素材
+func (b *WeChatBackend) Upload(ctx context.Context, localPath string) (*ImageInfo, error) {
+	result, err := b.client.UploadPermanentMedia(ctx, wechat.MediaTypeImage, localPath)
+	if err != nil {
+		return nil, fmt.Errorf("upload to wechat: %w", err)
+	}
+
+	return &ImageInfo{
+		MediaID: result.MediaID,
+		URL:     result.URL,
+	}, nil
+}
+
+// newBackend 根据配置创建正文图片后端
+func newBackend(client *wechat.Client, cfg *config.ImageConfig) (ImageBackend, error) {
+	switch cfg.Backend {
+	case "", "wechat":
+		return NewWeChatBackend(client), nil
+	case "s3":
+		return NewS3Backend(&cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown image backend: %s", cfg.Backend)
+	}
+}
@@ -0,0 +1,109 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/image/draw"
+)
+
+// 压缩参数：先逐步降低 JPEG 质量，仍超限时按比例缩小尺寸
+const (
+	compressMinQuality  = 40
+	compressQualityStep = 15
+	compressScale       = 0.75
+	compressMaxSteps    = 8
+)
+
+// compressImage 文件超过 image.max_size_bytes 时重新编码 JPEG/PNG 直至符合大小限制，
+// 结果保存为临时文件。其他格式 (如 GIF) 原样返回
+func (m *Manager) compressImage(localPath string) (string, error) {
+	limit := m.cfg.MaxSizeBytes
+	if limit <= 0 {
+		return localPath, nil
+	}
+
+	stat, err := os.Stat(localPath)
+	if err != nil {
+		return "", err
+	}
+	if stat.Size() <= limit {
+		return localPath, nil
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	img, format, err := image.Decode(file)
+	file.Close()
+	if err != nil || (format != "jpeg" && format != "png") {
+		slog.Warn("Image exceeds size limit but cannot be compressed, uploading as is",
+			"path", localPath, "size", stat.Size(), "limit", limit)
+		return localPath, nil
+	}
+
+	data, err := encodeWithinLimit(img, format, limit)
+	if err != nil {
+		return "", fmt.Errorf("compress %s: %w", localPath, err)
+	}
+
+	ext := ".jpg"
+	if format == "png" {
+		ext = ".png"
+	}
+	compressedPath := filepath.Join(m.cfg.TempDir, tempName(localPath, "_compressed", ext))
+	if err := os.WriteFile(compressedPath, data, 0644); err != nil {
+		return "", err
+	}
+	m.trackTempFile(compressedPath)
+
+	slog.Debug("Compressed image", "path", localPath, "before", stat.Size(), "after", len(data))
+	return compressedPath, nil
+}
+
+// encodeWithinLimit 依次降低质量、缩小尺寸重新编码，返回第一个不超过 limit 的结果
+func encodeWithinLimit(img image.Image, format string, limit int64) ([]byte, error) {
+	quality := 85
+	for step := 0; step < compressMaxSteps; step++ {
+		var buf bytes.Buffer
+		var err error
+		if format == "png" {
+			encoder := png.Encoder{CompressionLevel: png.BestCompression}
+			err = encoder.Encode(&buf, img)
+		} else {
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+		}
+		if err != nil {
+			return nil, err
+		}
+		if int64(buf.Len()) <= limit {
+			return buf.Bytes(), nil
+		}
+
+		// JPEG 优先降低质量，PNG 为无损格式只能缩小尺寸
+		if format == "jpeg" && quality-compressQualityStep >= compressMinQuality {
+			quality -= compressQualityStep
+			continue
+		}
+		img = scaleImage(img, compressScale)
+	}
+	return nil, fmt.Errorf("still exceeds %d bytes after %d attempts", limit, compressMaxSteps)
+}
+
+// scaleImage 按比例缩放图片
+func scaleImage(img image.Image, scale float64) image.Image {
+	bounds := img.Bounds()
+	width := max(1, int(float64(bounds.Dx())*scale))
+	height := max(1, int(float64(bounds.Dy())*scale))
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	return scaled
+}
@@ -0,0 +1,134 @@
+package media
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// uploadExts 微信图片素材接受的格式及对应扩展名
+var uploadExts = map[string]string{
+	"jpeg": ".jpg",
+	"png":  ".png",
+	"gif":  ".gif",
+	"bmp":  ".bmp",
+}
+
+// detectImageFormat 读取文件头识别图片格式，无法识别时返回空字符串
+func detectImageFormat(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 32)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	head := buf[:n]
+
+	switch {
+	case bytes.HasPrefix(head, []byte("\xff\xd8\xff")):
+		return "jpeg", nil
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "png", nil
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return "gif", nil
+	case bytes.HasPrefix(head, []byte("BM")):
+		return "bmp", nil
+	case len(head) >= 12 && bytes.HasPrefix(head, []byte("RIFF")) && string(head[8:12]) == "WEBP":
+		return "webp", nil
+	case bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		return "tiff", nil
+	case len(head) >= 12 && string(head[4:8]) == "ftyp" &&
+		(string(head[8:12]) == "avif" || string(head[8:12]) == "avis"):
+		return "avif", nil
+	}
+	return "", nil
+}
+
+// prepareFormat 按文件内容确保上传格式被微信接受：WebP、TIFF 转码为 JPEG，
+// 受支持的格式扩展名与内容不符时复制为正确的扩展名
+func (m *Manager) prepareFormat(localPath string) (string, error) {
+	format, err := detectImageFormat(localPath)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case "":
+		// 无法识别的内容交给微信判断
+		return localPath, nil
+	case "avif":
+		return "", fmt.Errorf("AVIF images are not supported, please convert %s to JPEG or PNG", localPath)
+	case "webp", "tiff":
+		return m.transcodeJPEG(localPath, format)
+	}
+
+	ext := uploadExts[format]
+	if strings.EqualFold(filepath.Ext(localPath), ext) ||
+		(format == "jpeg" && strings.EqualFold(filepath.Ext(localPath), ".jpeg")) {
+		return localPath, nil
+	}
+
+	// 扩展名与内容不符 (如保存为 .webp 的 JPEG)
+	fixedPath := filepath.Join(m.cfg.TempDir, tempName(localPath, "", ext))
+	if err := copyFile(localPath, fixedPath); err != nil {
+		return "", err
+	}
+	m.trackTempFile(fixedPath)
+	return fixedPath, nil
+}
+
+// transcodeJPEG 将微信不支持的格式转码为 JPEG，透明区域填充为白色
+func (m *Manager) transcodeJPEG(localPath, format string) (string, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	img, _, err := image.Decode(file)
+	file.Close()
+	if err != nil {
+		return "", fmt.Errorf("decode %s image: %w", format, err)
+	}
+
+	canvas := image.NewRGBA(img.Bounds())
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(canvas, canvas.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	jpegPath := filepath.Join(m.cfg.TempDir, tempName(localPath, "_converted", ".jpg"))
+	out, err := os.Create(jpegPath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	m.trackTempFile(jpegPath)
+
+	if err := jpeg.Encode(out, canvas, &jpeg.Options{Quality: 90}); err != nil {
+		return "", fmt.Errorf("encode jpeg: %w", err)
+	}
+
+	slog.Debug("Converted image to JPEG", "path", localPath, "format", format)
+	return jpegPath, nil
+}
+
+// tempName 生成临时文件名，带上源路径哈希避免不同目录的同名文件冲突
+func tempName(localPath, suffix, ext string) string {
+	base := strings.TrimSuffix(filepath.Base(localPath), filepath.Ext(localPath))
+	return fmt.Sprintf("%s_%x%s%s", base, md5.Sum([]byte(localPath)), suffix, ext)
+}
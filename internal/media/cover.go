@@ -0,0 +1,105 @@
+package media
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "image/gif"
+)
+
+// parseAspect 解析 "2.35:1" 形式的宽高比
+func parseAspect(aspect string) (float64, error) {
+	w, h, ok := strings.Cut(aspect, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid cover aspect %q, expected W:H", aspect)
+	}
+	width, err := strconv.ParseFloat(strings.TrimSpace(w), 64)
+	if err != nil || width <= 0 {
+		return 0, fmt.Errorf("invalid cover aspect %q", aspect)
+	}
+	height, err := strconv.ParseFloat(strings.TrimSpace(h), 64)
+	if err != nil || height <= 0 {
+		return 0, fmt.Errorf("invalid cover aspect %q", aspect)
+	}
+	return width / height, nil
+}
+
+// cropRect 计算裁剪区域：水平方向始终居中，垂直方向按 anchor 居中或保留顶部
+func cropRect(bounds image.Rectangle, ratio float64, anchor string) image.Rectangle {
+	w, h := bounds.Dx(), bounds.Dy()
+
+	if float64(w)/float64(h) > ratio {
+		cw := int(float64(h) * ratio)
+		x := bounds.Min.X + (w-cw)/2
+		return image.Rect(x, bounds.Min.Y, x+cw, bounds.Max.Y)
+	}
+
+	ch := int(float64(w) / ratio)
+	y := bounds.Min.Y + (h-ch)/2
+	if anchor == "top" {
+		y = bounds.Min.Y
+	}
+	return image.Rect(bounds.Min.X, y, bounds.Max.X, y+ch)
+}
+
+// cropCover 将封面裁剪为配置的宽高比，保存为临时文件。GIF 和无法解码的格式原样返回
+func (m *Manager) cropCover(localPath string) (string, error) {
+	ratio, err := parseAspect(m.cfg.CoverAspect)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	img, format, err := image.Decode(file)
+	if err != nil {
+		slog.Warn("Cover format not supported for cropping, uploading as is", "path", localPath, "error", err)
+		return localPath, nil
+	}
+	if format == "gif" {
+		return localPath, nil
+	}
+
+	rect := cropRect(img.Bounds(), ratio, m.cfg.CoverCrop)
+	if rect == img.Bounds() {
+		return localPath, nil
+	}
+
+	cropped := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), img, rect.Min, draw.Src)
+
+	ext := ".jpg"
+	if format == "png" {
+		ext = ".png"
+	}
+	croppedPath := filepath.Join(m.cfg.TempDir, tempName(localPath, "_cover", ext))
+
+	out, err := os.Create(croppedPath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	m.trackTempFile(croppedPath)
+
+	if format == "png" {
+		err = png.Encode(out, cropped)
+	} else {
+		err = jpeg.Encode(out, cropped, &jpeg.Options{Quality: 90})
+	}
+	if err != nil {
+		return "", fmt.Errorf("encode cover: %w", err)
+	}
+	return croppedPath, nil
+}
@@ -5,21 +5,32 @@ import (
 	"crypto/md5"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"auto-wx-post/internal/cache"
 	"auto-wx-post/internal/config"
+	"auto-wx-post/internal/metrics"
 	"auto-wx-post/internal/wechat"
+
+	"github.com/skip2/go-qrcode"
 )
 
+// MaxImageSize 微信永久图片素材大小上限
+const MaxImageSize = 10 << 20
+
 // Manager 媒体管理器
 type Manager struct {
 	client       *wechat.Client
+	backend      ImageBackend
+	cover        ImageBackend
 	cacheManager *cache.Manager
 	cfg          *config.ImageConfig
 	tempFiles    []string
@@ -28,8 +39,8 @@ type Manager struct {
 
 // ImageInfo 图片信息
 type ImageInfo struct {
-	MediaID string
-	URL     string
+	MediaID string `json:"media_id"`
+	URL     string `json:"url"`
 }
 
 // NewManager 创建媒体管理器
@@ -39,18 +50,48 @@ func NewManager(client *wechat.Client, cacheManager *cache.Manager, cfg *config.
 		return nil, fmt.Errorf("create temp dir: %w", err)
 	}
 
+	backend, err := newBackend(client, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create image backend: %w", err)
+	}
+
 	return &Manager{
 		client:       client,
+		backend:      backend,
+		cover:        NewWeChatBackend(client),
 		cacheManager: cacheManager,
 		cfg:          cfg,
 		tempFiles:    make([]string, 0),
 	}, nil
 }
 
-// UploadImage 上传图片 (支持URL和本地路径)
+// UploadImage 上传正文图片到配置的后端 (支持URL和本地路径)
 func (m *Manager) UploadImage(ctx context.Context, imagePath string) (*ImageInfo, error) {
+	return m.uploadWith(ctx, m.backend, imagePath, false)
+}
+
+// UploadCover 上传封面图片，始终使用微信素材以获得 thumb_media_id，
+// 配置了 cover_aspect 时先按比例裁剪
+func (m *Manager) UploadCover(ctx context.Context, imagePath string) (*ImageInfo, error) {
+	return m.uploadWith(ctx, m.cover, imagePath, true)
+}
+
+// CropsCover 是否配置了封面裁剪 (此时封面需单独上传)
+func (m *Manager) CropsCover() bool {
+	return m.cfg.CoverAspect != "" && m.cfg.CoverAspect != "none"
+}
+
+// uploadWith 使用指定后端上传图片
+func (m *Manager) uploadWith(ctx context.Context, backend ImageBackend, imagePath string, cover bool) (*ImageInfo, error) {
+	var variant string
+	if cover && m.CropsCover() {
+		variant = fmt.Sprintf("_cover_%s_%s", m.cfg.CoverAspect, m.cfg.CoverCrop)
+	}
+	cacheKey := m.imageDigest(backend, imagePath) + variant
+
 	// 检查缓存
-	if cached, exists := m.cacheManager.Get(m.imageDigest(imagePath)); exists {
+	if cached, exists := m.cacheManager.GetWithTTL(cacheKey, m.cacheTTL()); exists {
+		metrics.ImageCacheLookups.Inc("hit")
 		return m.parseCachedInfo(cached)
 	}
 
@@ -68,70 +109,376 @@ func (m *Manager) UploadImage(ctx context.Context, imagePath string) (*ImageInfo
 		localPath = imagePath
 	}
 
-	// 上传到微信
-	result, err := m.client.UploadPermanentMedia(ctx, wechat.MediaTypeImage, localPath)
+	// 按内容去重：不同地址或复制的同一张图片复用已上传的结果
+	digest, err := cache.FileDigest(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("hash image: %w", err)
+	}
+	contentKey := m.contentDigest(backend, digest) + variant
+	if cached, exists := m.cacheManager.GetWithTTL(contentKey, m.cacheTTL()); exists {
+		if info, err := m.parseCachedInfo(cached); err == nil {
+			slog.DebugContext(ctx, "Reusing upload of identical image", "path", imagePath, "url", info.URL)
+			metrics.ImageCacheLookups.Inc("hit")
+			if err := m.cacheManager.Set(cacheKey, cached); err != nil {
+				slog.WarnContext(ctx, "Failed to cache image", "path", imagePath, "error", err)
+			}
+			return info, nil
+		}
+	}
+
+	metrics.ImageCacheLookups.Inc("miss")
+
+	// GIF 保持原样上传以保留动画
+	localPath, err = m.prepareGIF(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("prepare gif: %w", err)
+	}
+
+	// WebP 等微信不支持的格式转码为 JPEG
+	localPath, err = m.prepareFormat(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("prepare format: %w", err)
+	}
+
+	// 封面按配置比例裁剪
+	if cover && m.CropsCover() {
+		localPath, err = m.cropCover(localPath)
+		if err != nil {
+			return nil, fmt.Errorf("crop cover: %w", err)
+		}
+	}
+
+	// 超过大小限制的图片压缩后上传
+	localPath, err = m.compressImage(localPath)
 	if err != nil {
-		return nil, fmt.Errorf("upload to wechat: %w", err)
+		return nil, fmt.Errorf("compress image: %w", err)
 	}
 
-	info := &ImageInfo{
-		MediaID: result.MediaID,
-		URL:     result.URL,
+	// 按配置调整素材库中显示的文件名
+	localPath, err = m.prepareUploadName(imagePath, localPath)
+	if err != nil {
+		return nil, fmt.Errorf("prepare upload name: %w", err)
+	}
+
+	start := time.Now()
+	info, err := backend.Upload(ctx, localPath)
+	metrics.ImageUploadDuration.ObserveSince(start)
+	if err != nil {
+		metrics.ImageUploadsTotal.Inc("failure")
+		return nil, err
+	}
+	metrics.ImageUploadsTotal.Inc("success")
+
+	// 上传后确认返回的地址可访问，失败时重新上传一次
+	if m.cfg.VerifyUpload && info.URL != "" {
+		if err := m.verifyUpload(ctx, info.URL); err != nil {
+			slog.WarnContext(ctx, "Uploaded image not reachable, re-uploading", "path", imagePath, "url", info.URL, "error", err)
+			if info, err = backend.Upload(ctx, localPath); err != nil {
+				return nil, err
+			}
+			if err := m.verifyUpload(ctx, info.URL); err != nil {
+				return nil, fmt.Errorf("verify upload %s: %w", info.URL, err)
+			}
+		}
 	}
 
 	// 缓存结果
-	cacheValue := fmt.Sprintf("%s|%s", info.MediaID, info.URL)
-	if err := m.cacheManager.Set(m.imageDigest(imagePath), cacheValue); err != nil {
-		// 缓存失败不影响主流程
-		fmt.Printf("warning: failed to cache image: %v\n", err)
+	cacheValue := formatCachedInfo(info)
+	for _, key := range []string{cacheKey, contentKey} {
+		if err := m.cacheManager.Set(key, cacheValue); err != nil {
+			// 缓存失败不影响主流程
+			slog.WarnContext(ctx, "Failed to cache image", "path", imagePath, "error", err)
+		}
 	}
 
 	return info, nil
 }
 
-// UploadImagesConcurrently 并发上传多个图片
-func (m *Manager) UploadImagesConcurrently(ctx context.Context, imagePaths []string, maxConcurrent int) (map[string]*ImageInfo, error) {
+// UploadImagesConcurrently 并发上传多个图片，返回成功的结果和失败的原因，均以图片路径为键。
+// 部分失败时仍返回已成功的图片，由调用方决定是否继续
+func (m *Manager) UploadImagesConcurrently(ctx context.Context, imagePaths []string, maxConcurrent int) (map[string]*ImageInfo, map[string]error) {
+	return m.uploadConcurrently(ctx, imagePaths, maxConcurrent, nil)
+}
+
+// UploadImagesResumable 并发上传多个图片，并将进度写入上传清单。
+// 清单中已完成的图片直接复用，全部成功后删除清单。返回值同 UploadImagesConcurrently，
+// error 仅表示清单无法读取
+func (m *Manager) UploadImagesResumable(ctx context.Context, imagePaths []string, maxConcurrent int, manifestPath string) (map[string]*ImageInfo, map[string]error, error) {
+	manifest, err := loadManifest(manifestPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results, failures := m.uploadConcurrently(ctx, imagePaths, maxConcurrent, manifest)
+	if len(failures) == 0 {
+		if rmErr := manifest.remove(); rmErr != nil {
+			slog.WarnContext(ctx, "Failed to remove upload manifest", "path", manifestPath, "error", rmErr)
+		}
+	}
+	return results, failures, nil
+}
+
+// uploadConcurrently 由 maxConcurrent 个 worker 从通道中领取图片上传，通道不带缓冲，
+// 图片再多也只有固定数量的 goroutine。manifest 不为 nil 时跳过已完成的图片并记录进度
+func (m *Manager) uploadConcurrently(ctx context.Context, imagePaths []string, maxConcurrent int, manifest *uploadManifest) (map[string]*ImageInfo, map[string]error) {
 	results := make(map[string]*ImageInfo)
+	failures := make(map[string]error)
 	var resultMutex sync.Mutex
 	var wg sync.WaitGroup
 
-	semaphore := make(chan struct{}, maxConcurrent)
-	errChan := make(chan error, len(imagePaths))
+	workers := maxConcurrent
+	if workers > len(imagePaths) {
+		workers = len(imagePaths)
+	}
+	if workers < 1 {
+		workers = 1
+	}
 
-	for _, imagePath := range imagePaths {
+	jobs := make(chan string)
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go func(path string) {
+		go func() {
 			defer wg.Done()
+			for path := range jobs {
+				info, err := m.uploadWithManifest(ctx, path, manifest)
+
+				resultMutex.Lock()
+				if err != nil {
+					failures[path] = err
+				} else {
+					results[path] = info
+				}
+				resultMutex.Unlock()
+			}
+		}()
+	}
+
+	// 发送方在 worker 全忙时阻塞；ctx 取消后剩余图片不再派发，记为失败
+	for i, imagePath := range imagePaths {
+		select {
+		case jobs <- imagePath:
+			continue
+		case <-ctx.Done():
+		}
+
+		resultMutex.Lock()
+		for _, skipped := range imagePaths[i:] {
+			failures[skipped] = ctx.Err()
+		}
+		resultMutex.Unlock()
+		break
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, failures
+}
+
+// uploadWithManifest 上传单张图片，清单中已有记录时直接复用
+func (m *Manager) uploadWithManifest(ctx context.Context, path string, manifest *uploadManifest) (*ImageInfo, error) {
+	if manifest != nil {
+		if info, _ := manifest.get(path); info != nil {
+			return info, nil
+		}
+	}
+
+	info, err := m.UploadImage(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil {
+		if err := manifest.record(path, info); err != nil {
+			slog.WarnContext(ctx, "Failed to record upload manifest", "path", path, "error", err)
+		}
+	}
+	return info, nil
+}
+
+// prepareGIF 通过内容嗅探识别GIF，确保以 .gif 扩展名原样上传，避免被当作静态图处理
+func (m *Manager) prepareGIF(localPath string) (string, error) {
+	contentType, err := sniffContentType(localPath)
+	if err != nil {
+		return "", err
+	}
+	if contentType != "image/gif" {
+		return localPath, nil
+	}
 
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > MaxImageSize {
+		slog.Warn("GIF exceeds WeChat image size limit, upload may fail or lose animation",
+			"path", localPath, "size", info.Size(), "limit", MaxImageSize)
+	}
 
-			info, err := m.UploadImage(ctx, path)
-			if err != nil {
-				errChan <- fmt.Errorf("upload %s: %w", path, err)
-				return
+	if strings.EqualFold(filepath.Ext(localPath), ".gif") {
+		return localPath, nil
+	}
+
+	// 扩展名与内容不符 (如无扩展名的URL默认保存为 .png)，复制为临时目录中的 .gif
+	gifPath := filepath.Join(m.cfg.TempDir, tempName(localPath, "", ".gif"))
+	if err := copyFile(localPath, gifPath); err != nil {
+		return "", err
+	}
+	m.trackTempFile(gifPath)
+
+	return gifPath, nil
+}
+
+// prepareUploadName 为同名但内容不同的图片生成可区分的上传文件名：
+// hash 追加内容哈希前缀，folder 加上来源目录名。文件复制到临时目录后上传
+func (m *Manager) prepareUploadName(sourcePath, localPath string) (string, error) {
+	if m.cfg.UploadName == "" {
+		return localPath, nil
+	}
+
+	// 下载的图片使用URL中的原始文件名
+	name := filepath.Base(sourcePath)
+	dir := filepath.Base(filepath.Dir(sourcePath))
+	if isURL(sourcePath) {
+		if u, err := url.Parse(sourcePath); err == nil {
+			name = path.Base(u.Path)
+			dir = path.Base(path.Dir(u.Path))
+		}
+	}
+	ext := filepath.Ext(localPath)
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+
+	switch m.cfg.UploadName {
+	case "hash":
+		data, err := os.ReadFile(localPath)
+		if err != nil {
+			return "", err
+		}
+		hash := fmt.Sprintf("%x", md5.Sum(data))
+		name = fmt.Sprintf("%s_%s%s", stem, hash[:8], ext)
+	case "folder":
+		if dir == "." || dir == "/" {
+			return localPath, nil
+		}
+		name = dir + "_" + stem + ext
+	}
+
+	// 每个文件使用独立的临时目录，避免并发上传时同名文件相互覆盖
+	tempDir, err := os.MkdirTemp(m.cfg.TempDir, "upload-")
+	if err != nil {
+		return "", err
+	}
+	namedPath := filepath.Join(tempDir, name)
+	if err := copyFile(localPath, namedPath); err != nil {
+		os.RemoveAll(tempDir)
+		return "", err
+	}
+	m.trackTempFile(namedPath)
+	m.trackTempFile(tempDir)
+
+	return namedPath, nil
+}
+
+// sniffContentType 读取文件头嗅探内容类型
+func sniffContentType(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 512)
+	n, err := file.Read(buf)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// copyFile 复制文件
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// CheckImages 预检查图片是否存在且可读，汇总所有失败项后一次性返回
+func (m *Manager) CheckImages(ctx context.Context, imagePaths []string) error {
+	var missing []string
+
+	for _, imagePath := range imagePaths {
+		if isURL(imagePath) {
+			if !m.cfg.CheckRemote {
+				continue
+			}
+			if err := m.headImage(ctx, imagePath); err != nil {
+				missing = append(missing, fmt.Sprintf("%s (%v)", imagePath, err))
 			}
+			continue
+		}
 
-			resultMutex.Lock()
-			results[path] = info
-			resultMutex.Unlock()
-		}(imagePath)
+		file, err := os.Open(imagePath)
+		if err != nil {
+			missing = append(missing, fmt.Sprintf("%s (%v)", imagePath, err))
+			continue
+		}
+		file.Close()
 	}
 
-	wg.Wait()
-	close(errChan)
+	if len(missing) > 0 {
+		return fmt.Errorf("%d image(s) missing or unreadable:\n  %s",
+			len(missing), strings.Join(missing, "\n  "))
+	}
+	return nil
+}
 
-	// 收集错误
-	var errs []error
-	for err := range errChan {
-		errs = append(errs, err)
+// headImage 发送HEAD请求检查远程图片是否可访问
+func (m *Manager) headImage(ctx context.Context, imgURL string) error {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", imgURL, nil)
+	if err != nil {
+		return err
 	}
 
-	if len(errs) > 0 {
-		return results, fmt.Errorf("upload errors: %v", errs)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
 	}
+	resp.Body.Close()
 
-	return results, nil
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("http error: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// verifyUpload 对上传后的地址发送HEAD请求，考虑到最终一致性，失败时间隔递增重试
+func (m *Manager) verifyUpload(ctx context.Context, imgURL string) error {
+	const attempts = 3
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = m.headImage(ctx, imgURL); err == nil {
+			return nil
+		}
+		if i == attempts-1 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(i+1) * time.Second):
+		}
+	}
+	return err
 }
 
 // downloadImage 下载图片到临时目录
@@ -211,17 +558,38 @@ func (m *Manager) Cleanup() error {
 	return nil
 }
 
-// imageDigest 计算图片标识
-func (m *Manager) imageDigest(imagePath string) string {
+// imageDigest 计算图片标识 (非微信后端带上后端名，避免与素材缓存混用)
+func (m *Manager) imageDigest(backend ImageBackend, imagePath string) string {
 	hash := md5.Sum([]byte(imagePath))
+	if name := backend.Name(); name != "wechat" {
+		return fmt.Sprintf("img_%s_%x", name, hash)
+	}
 	return fmt.Sprintf("img_%x", hash)
 }
 
-// parseCachedInfo 解析缓存信息
+// cacheTTL 图片缓存有效期，过期后重新上传 (素材可能已在后台被删除)
+func (m *Manager) cacheTTL() time.Duration {
+	return time.Duration(m.cfg.CacheTTL) * time.Hour
+}
+
+// contentDigest 按图片内容MD5计算的二级缓存键
+func (m *Manager) contentDigest(backend ImageBackend, digest string) string {
+	if name := backend.Name(); name != "wechat" {
+		return fmt.Sprintf("imgc_%s_%s", name, digest)
+	}
+	return "imgc_" + digest
+}
+
+// formatCachedInfo 生成缓存信息，与 parseCachedInfo 对应
+func formatCachedInfo(info *ImageInfo) string {
+	return info.MediaID + "|" + info.URL
+}
+
+// parseCachedInfo 解析缓存信息 ("<media_id>|<url>"，media_id 不含 "|"，URL 可能包含)
 func (m *Manager) parseCachedInfo(cached string) (*ImageInfo, error) {
-	var mediaID, url string
-	if _, err := fmt.Sscanf(cached, "%s|%s", &mediaID, &url); err != nil {
-		return nil, fmt.Errorf("parse cached info: %w", err)
+	mediaID, url, ok := strings.Cut(cached, "|")
+	if !ok || url == "" {
+		return nil, fmt.Errorf("parse cached info: invalid value %q", cached)
 	}
 	return &ImageInfo{MediaID: mediaID, URL: url}, nil
 }
@@ -230,3 +598,14 @@ func (m *Manager) parseCachedInfo(cached string) (*ImageInfo, error) {
 func isURL(path string) bool {
 	return len(path) > 7 && (path[:7] == "http://" || path[:8] == "https://")
 }
+
+// UploadQRCode 生成内容的二维码图片并上传。文件名由内容和尺寸决定，重复发布时命中缓存
+func (m *Manager) UploadQRCode(ctx context.Context, content string, size int) (*ImageInfo, error) {
+	qrPath := filepath.Join(m.cfg.TempDir, fmt.Sprintf("qr_%x_%d.png", md5.Sum([]byte(content)), size))
+	if err := qrcode.WriteFile(content, qrcode.Medium, size, qrPath); err != nil {
+		return nil, fmt.Errorf("generate qr code: %w", err)
+	}
+	m.trackTempFile(qrPath)
+
+	return m.UploadImage(ctx, qrPath)
+}
@@ -0,0 +1,57 @@
+package media
+
+import (
+	"path/filepath"
+	"testing"
+
+	"auto-wx-post/internal/cache"
+)
+
+func TestCachedInfoRoundTrip(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "cache.json")
+	cacheManager, err := cache.NewManager(storePath, cache.KeyStrategyContent)
+	if err != nil {
+		t.Fatalf("create cache: %v", err)
+	}
+
+	tests := []*ImageInfo{
+		{MediaID: "media1", URL: "https://mmbiz.qpic.cn/a.png"},
+		{MediaID: "media2", URL: "https://cdn.example.com/a.png?x-oss-process=image/resize,w_800&sign=a|b|c"},
+		{MediaID: "", URL: "https://bucket.s3.amazonaws.com/a.png?X-Amz-Signature=abc&v=1|2"},
+	}
+
+	for _, info := range tests {
+		if err := cacheManager.Set(info.URL, formatCachedInfo(info)); err != nil {
+			t.Fatalf("store %q: %v", info.URL, err)
+		}
+	}
+
+	// 从文件重新加载，确认写入磁盘后仍能还原
+	reloaded, err := cache.NewManager(storePath, cache.KeyStrategyContent)
+	if err != nil {
+		t.Fatalf("reload cache: %v", err)
+	}
+	m := &Manager{cacheManager: reloaded}
+	for _, want := range tests {
+		cached, ok := reloaded.Get(want.URL)
+		if !ok {
+			t.Fatalf("retrieve %q: not found", want.URL)
+		}
+		got, err := m.parseCachedInfo(cached)
+		if err != nil {
+			t.Fatalf("parseCachedInfo(%q): %v", cached, err)
+		}
+		if *got != *want {
+			t.Errorf("round trip = %+v, want %+v", *got, *want)
+		}
+	}
+}
+
+func TestParseCachedInfoInvalid(t *testing.T) {
+	m := &Manager{}
+	for _, value := range []string{"", "media-only", "media|"} {
+		if _, err := m.parseCachedInfo(value); err == nil {
+			t.Errorf("parseCachedInfo(%q) succeeded, want error", value)
+		}
+	}
+}
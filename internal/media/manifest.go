@@ -0,0 +1,70 @@
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// uploadManifest 单篇文章的图片上传清单，每张图片完成后立即落盘，
+// 中断后重新运行可跳过已上传的图片
+type uploadManifest struct {
+	path    string
+	entries map[string]*ImageInfo
+	mutex   sync.Mutex
+}
+
+// loadManifest 加载上传清单，文件不存在时返回空清单
+func loadManifest(path string) (*uploadManifest, error) {
+	m := &uploadManifest{
+		path:    path,
+		entries: make(map[string]*ImageInfo),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &m.entries); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return m, nil
+}
+
+// get 获取已完成的上传
+func (m *uploadManifest) get(source string) (*ImageInfo, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	info, ok := m.entries[source]
+	return info, ok
+}
+
+// record 记录一张图片的上传结果并写入文件 (先写临时文件再重命名，避免中断时损坏)
+func (m *uploadManifest) record(source string, info *ImageInfo) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.entries[source] = info
+	data, err := json.MarshalIndent(m.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+
+	tmpPath := m.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return os.Rename(tmpPath, m.path)
+}
+
+// remove 全部图片上传完成后删除清单
+func (m *uploadManifest) remove() error {
+	if err := os.Remove(m.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"auto-wx-post/internal/config"
+)
+
+// S3Backend 上传到 S3 兼容的对象存储 (AWS S3、阿里云OSS、腾讯云COS、MinIO等)
+type S3Backend struct {
+	cfg        *config.S3Config
+	httpClient *http.Client
+}
+
+// NewS3Backend 创建 S3 兼容后端
+func NewS3Backend(cfg *config.S3Config) (*S3Backend, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 endpoint and bucket are required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 access_key_id and secret_access_key are required")
+	}
+
+	return &S3Backend{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// Name 后端名称
+func (b *S3Backend) Name() string {
+	return "s3"
+}
+
+// Upload 以内容哈希为对象名上传图片，返回公开访问URL
+func (b *S3Backend) Upload(ctx context.Context, localPath string) (*ImageInfo, error) {
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	objectKey := fmt.Sprintf("%x%s", md5.Sum(data), strings.ToLower(filepath.Ext(localPath)))
+	if prefix := strings.Trim(b.cfg.Prefix, "/"); prefix != "" {
+		objectKey = prefix + "/" + objectKey
+	}
+
+	objectURL, err := b.objectURL(objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", objectURL.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", http.DetectContentType(data))
+	b.sign(req, data, time.Now().UTC())
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("upload to s3: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("s3 error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	publicURL := objectURL.String()
+	if b.cfg.PublicURL != "" {
+		publicURL = strings.TrimRight(b.cfg.PublicURL, "/") + "/" + objectKey
+	}
+
+	return &ImageInfo{URL: publicURL}, nil
+}
+
+// objectURL 构造对象地址 (默认虚拟主机风格，path_style 为路径风格)
+func (b *S3Backend) objectURL(objectKey string) (*url.URL, error) {
+	endpoint, err := url.Parse(b.cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse s3 endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" {
+		endpoint.Scheme = "https"
+	}
+
+	u := &url.URL{Scheme: endpoint.Scheme, Host: endpoint.Host}
+	if b.cfg.PathStyle {
+		u.Path = "/" + b.cfg.Bucket + "/" + objectKey
+	} else {
+		u.Host = b.cfg.Bucket + "." + endpoint.Host
+		u.Path = "/" + objectKey
+	}
+	return u, nil
+}
+
+// sign 使用 AWS Signature V4 签名请求
+func (b *S3Backend) sign(req *http.Request, payload []byte, now time.Time) {
+	region := b.cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf("content-type:%s\nhost:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n",
+		req.Header.Get("Content-Type"), req.URL.Host, payloadHash, amzDate)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", dateStamp, region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+b.cfg.SecretAccessKey), dateStamp)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		b.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
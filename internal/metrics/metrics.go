@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 发布、上传、令牌和缓存指标，在包初始化时注册一次，各组件直接调用
+var (
+	// PublishTotal 文章发布次数，result 为 success、failure 或 skipped (已发布或内容未变化)
+	PublishTotal = NewCounter("awp_publish_total", "Articles published, by result.", "result")
+	// ImageUploadsTotal 图片上传次数 (不含缓存命中)，result 为 success 或 failure
+	ImageUploadsTotal = NewCounter("awp_image_uploads_total", "Image uploads to the storage backend, by result.", "result")
+	// ImageUploadDuration 单张图片上传到存储后端的耗时
+	ImageUploadDuration = NewHistogram("awp_image_upload_duration_seconds", "Time spent uploading one image to the storage backend.",
+		[]float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30})
+	// ImageCacheLookups 图片上传缓存查询次数，result 为 hit 或 miss
+	ImageCacheLookups = NewCounter("awp_image_cache_lookups_total", "Image upload cache lookups, by result.", "result")
+	// TokenRefreshesTotal access_token 刷新次数，result 为 success 或 failure
+	TokenRefreshesTotal = NewCounter("awp_token_refreshes_total", "WeChat access token refreshes, by result.", "result")
+)
+
+// collector 可输出为 Prometheus 文本格式的指标
+type collector interface {
+	write(w io.Writer)
+	metricName() string
+}
+
+var (
+	registryMutex sync.Mutex
+	registry      = make(map[string]collector)
+)
+
+// register 注册指标，名称重复时 panic (指标只应在包初始化时创建一次)
+func register(c collector) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, ok := registry[c.metricName()]; ok {
+		panic(fmt.Sprintf("metrics: %s registered twice", c.metricName()))
+	}
+	registry[c.metricName()] = c
+}
+
+// WriteText 以 Prometheus 文本格式输出全部指标，按名称排序
+func WriteText(w io.Writer) error {
+	registryMutex.Lock()
+	collectors := make([]collector, 0, len(registry))
+	for _, c := range registry {
+		collectors = append(collectors, c)
+	}
+	registryMutex.Unlock()
+
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].metricName() < collectors[j].metricName() })
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Counter 单调递增计数器，可按一个标签区分 (线程安全)
+type Counter struct {
+	name   string
+	help   string
+	label  string
+	mutex  sync.Mutex
+	values map[string]float64
+}
+
+// NewCounter 创建并注册计数器，label 为空表示不带标签
+func NewCounter(name, help, label string) *Counter {
+	c := &Counter{name: name, help: help, label: label, values: make(map[string]float64)}
+	register(c)
+	return c
+}
+
+// Inc 计数加一，value 为标签值
+func (c *Counter) Inc(value string) {
+	c.Add(value, 1)
+}
+
+// Add 计数增加 delta
+func (c *Counter) Add(value string, delta float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.values[value] += delta
+}
+
+func (c *Counter) metricName() string { return c.name }
+
+func (c *Counter) write(w io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	values := make([]string, 0, len(c.values))
+	for v := range c.values {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	for _, v := range values {
+		if c.label == "" {
+			fmt.Fprintf(w, "%s %s\n", c.name, formatFloat(c.values[v]))
+			continue
+		}
+		fmt.Fprintf(w, "%s{%s=\"%s\"} %s\n", c.name, c.label, escapeLabel(v), formatFloat(c.values[v]))
+	}
+}
+
+// Histogram 直方图，记录观测值的分布 (线程安全)
+type Histogram struct {
+	name    string
+	help    string
+	buckets []float64
+	mutex   sync.Mutex
+	counts  []uint64 // 各桶的计数 (非累计)
+	sum     float64
+	count   uint64
+}
+
+// NewHistogram 创建并注册直方图，buckets 为升序的上界
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	h := &Histogram{name: name, help: help, buckets: buckets, counts: make([]uint64, len(buckets))}
+	register(h)
+	return h
+}
+
+// Observe 记录一个观测值
+func (h *Histogram) Observe(v float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.sum += v
+	h.count++
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+}
+
+// ObserveSince 记录从 start 开始经过的秒数
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+func (h *Histogram) metricName() string { return h.name }
+
+func (h *Histogram) write(w io.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	var cumulative uint64
+	for i, bound := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.name, formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", h.name, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", h.name, h.count)
+}
+
+// formatFloat 按 Prometheus 文本格式输出数值
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escapeLabel 转义标签值中的反斜杠、引号和换行
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
@@ -0,0 +1,76 @@
+package publisher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"auto-wx-post/internal/markdown"
+	"auto-wx-post/internal/media"
+	"auto-wx-post/internal/wechat"
+)
+
+// dryRunMediaID 模拟运行时代替封面 media_id 的占位值
+const dryRunMediaID = "dry-run"
+
+// dryRunMeta 模拟运行时与HTML一同写出的元数据
+type dryRunMeta struct {
+	FilePath   string            `json:"file_path"`
+	Title      string            `json:"title"`
+	Author     string            `json:"author,omitempty"`
+	Digest     string            `json:"digest,omitempty"`
+	Date       string            `json:"date,omitempty"`
+	SourceURL  string            `json:"source_url,omitempty"`
+	Tags       []string          `json:"tags,omitempty"`
+	Categories []string          `json:"categories,omitempty"`
+	Images     []string          `json:"images,omitempty"`
+	Meta       map[string]string `json:"meta,omitempty"`
+}
+
+// stubImages 模拟运行时不上传图片，保留原地址
+func stubImages(images []string) map[string]*media.ImageInfo {
+	imageMap := make(map[string]*media.ImageInfo, len(images))
+	for _, image := range images {
+		imageMap[image] = &media.ImageInfo{URL: image}
+	}
+	return imageMap
+}
+
+// writeDryRun 将最终HTML和元数据写入 <dryRunDir>/<slug>/，返回HTML路径
+func (p *Publisher) writeDryRun(filePath string, article *markdown.Article, payload wechat.Article) (string, error) {
+	filename := filepath.Base(filePath)
+	slug := strings.TrimSuffix(filename, filepath.Ext(filename))
+	dir := filepath.Join(p.dryRunDir, slug)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create dry run dir: %w", err)
+	}
+
+	htmlPath := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(htmlPath, []byte(payload.Content), 0644); err != nil {
+		return "", fmt.Errorf("write dry run html: %w", err)
+	}
+
+	data, err := json.MarshalIndent(dryRunMeta{
+		FilePath:   filePath,
+		Title:      payload.Title,
+		Author:     payload.Author,
+		Digest:     payload.Digest,
+		Date:       article.Date,
+		SourceURL:  payload.ContentSourceURL,
+		Tags:       article.Tags,
+		Categories: article.Categories,
+		Images:     article.Images,
+		Meta:       article.Meta,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal dry run metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "meta.json"), data, 0644); err != nil {
+		return "", fmt.Errorf("write dry run metadata: %w", err)
+	}
+
+	p.log.Info("Dry run output written", "path", htmlPath)
+	return htmlPath, nil
+}
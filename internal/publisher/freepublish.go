@@ -0,0 +1,70 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"auto-wx-post/internal/wechat"
+)
+
+// freePublishPollInterval 轮询发布状态的间隔
+const freePublishPollInterval = 5 * time.Second
+
+// freePublish 提交草稿发布并轮询直到发布状态为最终状态
+func (p *Publisher) freePublish(ctx context.Context, mediaID string, result *PublishResult) error {
+	p.log.InfoContext(ctx, "Submitting draft for publishing", "media_id", mediaID)
+	publishID, err := p.wechatClient.SubmitFreePublish(ctx, mediaID)
+	if err != nil {
+		return fmt.Errorf("submit: %w", err)
+	}
+	result.PublishID = publishID
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(p.cfg.Publish.AutoPublishTimeout)*time.Second)
+	defer cancel()
+
+	ticker := time.NewTicker(freePublishPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for publish %s: %w", publishID, ctx.Err())
+		case <-ticker.C:
+		}
+
+		status, err := p.wechatClient.GetFreePublishStatus(ctx, publishID)
+		if err != nil {
+			return fmt.Errorf("get status: %w", err)
+		}
+		if !status.Final() {
+			continue
+		}
+		if !status.Succeeded() {
+			return fmt.Errorf("publish %s failed with status %d (%s)",
+				publishID, status.PublishStatus, publishStatusText(status.PublishStatus))
+		}
+
+		result.ArticleURL = status.ArticleURL()
+		p.log.InfoContext(ctx, "Article published", "publish_id", publishID, "url", result.ArticleURL)
+		return nil
+	}
+}
+
+// publishStatusText 发布状态说明
+func publishStatusText(status int) string {
+	switch status {
+	case wechat.PublishStatusOriginalFail:
+		return "original content review failed"
+	case wechat.PublishStatusFailed:
+		return "publish failed"
+	case wechat.PublishStatusAuditFail:
+		return "platform review failed"
+	case wechat.PublishStatusDeleted:
+		return "deleted after publishing"
+	case wechat.PublishStatusBanned:
+		return "banned after publishing"
+	default:
+		return "unknown status"
+	}
+}
@@ -0,0 +1,161 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+
+	"auto-wx-post/internal/cache"
+	"auto-wx-post/internal/logger"
+	"auto-wx-post/internal/metrics"
+	"auto-wx-post/internal/wechat"
+)
+
+// GroupResult 多图文草稿发布结果
+type GroupResult struct {
+	MediaID    string           `json:"media_id,omitempty"`
+	Articles   []*PublishResult `json:"articles"` // 按草稿中的顺序，第一篇为头条
+	DryRun     bool             `json:"dry_run,omitempty"`
+	PublishID  string           `json:"publish_id,omitempty"`
+	ArticleURL string           `json:"article_url,omitempty"`
+}
+
+// PublishArticleGroup 将多篇文章合并为一篇多图文草稿 (最多 8 篇)。顺序由 OrderArticles 决定，
+// 头条的封面即为整组封面，可通过 front matter 的 order 指定头条。
+// 任一篇处理失败时整组不提交；组内文章不按 split_threshold 拆分，也不更新已有草稿
+func (p *Publisher) PublishArticleGroup(ctx context.Context, filePaths []string) (*GroupResult, error) {
+	group, err := p.publishGroup(ctx, filePaths)
+	if err != nil {
+		metrics.PublishTotal.Add("failure", float64(len(filePaths)))
+	} else {
+		metrics.PublishTotal.Add("success", float64(len(group.Articles)))
+	}
+	return group, err
+}
+
+// publishGroup 渲染并提交多图文草稿
+func (p *Publisher) publishGroup(ctx context.Context, filePaths []string) (*GroupResult, error) {
+	ctx = logger.EnsureRequestID(ctx)
+	if len(filePaths) == 0 {
+		return nil, fmt.Errorf("no articles to group")
+	}
+	if len(filePaths) > wechat.MaxGroupArticles {
+		return nil, fmt.Errorf("too many articles for one draft: %d (max %d)", len(filePaths), wechat.MaxGroupArticles)
+	}
+
+	ordered, err := p.OrderArticles(filePaths)
+	if err != nil {
+		return nil, fmt.Errorf("order articles: %w", err)
+	}
+	p.log.InfoContext(ctx, "Publishing article group", "count", len(ordered))
+
+	group := &GroupResult{Articles: make([]*PublishResult, len(ordered))}
+	drafts := make([]*draft, len(ordered))
+	payloads := make([]wechat.Article, len(ordered))
+	contentHashes := make([]string, len(ordered))
+	for i, filePath := range ordered {
+		result := &PublishResult{FilePath: filePath}
+
+		if hook := p.cfg.Publish.PreHook; hook != "" {
+			if err := p.runHook(ctx, hook, filePath, nil); err != nil {
+				return nil, fmt.Errorf("%s: pre hook: %w", filePath, err)
+			}
+		}
+
+		var existingThumb string
+		if record, ok := p.cacheManager.GetPublishRecord(filePath); ok {
+			existingThumb = record.ThumbMediaID
+		}
+		d, err := p.prepareDraft(ctx, filePath, result, existingThumb)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		if err := validateDraft(d.payload); err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+
+		contentHash, err := cache.FileDigest(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("hash source file: %w", err)
+		}
+
+		result.Title = d.article.Title
+		result.SourceURL = d.payload.ContentSourceURL
+		result.TagID = d.article.TagID
+		group.Articles[i] = result
+		drafts[i] = d
+		payloads[i] = d.payload
+		contentHashes[i] = contentHash
+	}
+
+	// 模拟运行只校验草稿数据
+	if p.dryRun {
+		for i, d := range drafts {
+			if p.dryRunDir != "" {
+				outPath, err := p.writeDryRun(ordered[i], d.article, d.payload)
+				if err != nil {
+					return nil, err
+				}
+				group.Articles[i].OutputPath = outPath
+			}
+			group.Articles[i].DryRun = true
+		}
+		p.log.InfoContext(ctx, "Dry run: group payload is valid", "count", len(drafts))
+		group.DryRun = true
+		return group, nil
+	}
+
+	p.log.InfoContext(ctx, "Adding article group to WeChat draft", "head", payloads[0].Title, "count", len(payloads))
+	mediaID, err := p.wechatClient.AddDraft(ctx, payloads)
+	if err != nil {
+		return nil, fmt.Errorf("add draft: %w", err)
+	}
+	p.log.InfoContext(ctx, "Successfully published group", "media_id", mediaID)
+	group.MediaID = mediaID
+
+	// 自动提交发布，失败时草稿仍保留，警告记录在头条结果中
+	if p.cfg.Publish.AutoPublish {
+		head := group.Articles[0]
+		if err := p.freePublish(ctx, mediaID, head); err != nil {
+			p.log.WarnContext(ctx, "Failed to publish draft", "media_id", mediaID, "error", err)
+			head.Warnings = append(head.Warnings, fmt.Sprintf("auto publish: %v", err))
+		}
+		group.PublishID = head.PublishID
+		group.ArticleURL = head.ArticleURL
+	}
+
+	for i, d := range drafts {
+		filePath := ordered[i]
+		result := group.Articles[i]
+		result.MediaID = mediaID
+
+		if p.savesHTML() {
+			if err := p.saveHTML(filePath, d.payload.Content); err != nil {
+				p.log.WarnContext(ctx, "Failed to save rendered HTML", "error", err)
+			}
+		}
+
+		if err := p.cacheManager.MarkFileProcessed(filePath, cache.FileRecord{
+			MediaID:      mediaID,
+			HTMLHash:     d.payloadHash,
+			ContentHash:  contentHashes[i],
+			ThumbMediaID: d.payload.ThumbMediaID,
+			TagID:        d.article.TagID,
+			GroupIndex:   i,
+		}); err != nil {
+			p.log.WarnContext(ctx, "Failed to mark as processed", "error", err)
+		}
+
+		if hook := p.cfg.Publish.PostHook; hook != "" {
+			env := map[string]string{
+				"AWP_TITLE":    d.article.Title,
+				"AWP_MEDIA_ID": mediaID,
+			}
+			if err := p.runHook(ctx, hook, filePath, env); err != nil {
+				p.log.WarnContext(ctx, "Post hook failed", "error", err)
+				result.Warnings = append(result.Warnings, fmt.Sprintf("post hook: %v", err))
+			}
+		}
+	}
+
+	return group, nil
+}
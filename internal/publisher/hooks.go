@@ -0,0 +1,58 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// runHook 执行外部钩子命令，文件路径作为最后一个参数传入，文章信息通过环境变量传递。
+// 命令以非零状态退出时返回包含其 stderr 的错误
+func (p *Publisher) runHook(ctx context.Context, command, filePath string, env map[string]string) error {
+	if strings.TrimSpace(command) == "" {
+		return nil
+	}
+
+	if p.cfg.Publish.HookTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(p.cfg.Publish.HookTimeout)*time.Second)
+		defer cancel()
+	}
+
+	cmd := hookCommand(ctx, command, filePath)
+	cmd.Env = os.Environ()
+	cmd.Env = append(cmd.Env, "AWP_FILE="+filePath)
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	p.log.InfoContext(ctx, "Running hook", "command", command, "file", filePath)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("hook %q: %w", command, ctx.Err())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("hook %q: %w: %s", command, err, msg)
+		}
+		return fmt.Errorf("hook %q: %w", command, err)
+	}
+	return nil
+}
+
+// hookCommand 通过 sh -c 执行钩子，支持引号、管道等 shell 语法，文件路径经 "$@" 追加为最后一个参数。
+// Windows 没有 sh，按空白拆分后直接执行，不支持引号
+func hookCommand(ctx context.Context, command, filePath string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		args := strings.Fields(command)
+		return exec.CommandContext(ctx, args[0], append(args[1:], filePath)...)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command+` "$@"`, "sh", filePath)
+}
@@ -0,0 +1,43 @@
+package publisher
+
+import (
+	"time"
+
+	"auto-wx-post/internal/wechat"
+)
+
+// Pacer 根据上一次发布结果决定下一次发布前的等待时间
+type Pacer struct {
+	interval   time.Duration
+	backoff    time.Duration
+	maxBackoff time.Duration
+	current    time.Duration
+}
+
+// NewPacer 创建发布节奏控制器
+func NewPacer(interval, backoff time.Duration) *Pacer {
+	return &Pacer{
+		interval:   interval,
+		backoff:    backoff,
+		maxBackoff: 16 * backoff,
+	}
+}
+
+// Next 返回下一次发布前应等待的时间：成功时使用常规间隔，
+// 遇到限流时退避，连续限流则加倍直到上限
+func (p *Pacer) Next(err error) time.Duration {
+	if err == nil || !wechat.IsRateLimited(err) {
+		p.current = 0
+		return p.interval
+	}
+
+	if p.current == 0 {
+		p.current = p.backoff
+	} else {
+		p.current *= 2
+	}
+	if p.current > p.maxBackoff {
+		p.current = p.maxBackoff
+	}
+	return p.current
+}
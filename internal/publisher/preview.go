@@ -0,0 +1,34 @@
+package publisher
+
+import (
+	"context"
+
+	"auto-wx-post/internal/markdown"
+)
+
+// RenderHTML 使用与发布相同的渲染流程 (结构检查、图片说明、文末二维码、front matter 指定的主题) 渲染文章，
+// 返回最终HTML和警告。不上传正文图片，正文中保留原图片地址
+func (p *Publisher) RenderHTML(ctx context.Context, filePath string) (string, *markdown.Article, []string, error) {
+	result := &PublishResult{FilePath: filePath}
+	article, err := p.loadArticle(ctx, filePath, result)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	// 视频封面与正文图片一样保留原地址
+	article.Posters = stubPosters(markdown.VideoPosters(article.Content))
+
+	rendered, err := p.renderArticle(ctx, filePath, article, nil, result)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return rendered.html, article, result.Warnings, nil
+}
+
+// stubPosters 预览时视频封面映射到原地址
+func stubPosters(posters []string) map[string]string {
+	urls := make(map[string]string, len(posters))
+	for _, poster := range posters {
+		urls[poster] = poster
+	}
+	return urls
+}
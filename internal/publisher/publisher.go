@@ -2,20 +2,39 @@ package publisher
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
+	"net/url"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"auto-wx-post/internal/cache"
 	"auto-wx-post/internal/config"
 	"auto-wx-post/internal/logger"
 	"auto-wx-post/internal/markdown"
 	"auto-wx-post/internal/media"
+	"auto-wx-post/internal/metrics"
 	"auto-wx-post/internal/wechat"
 )
 
+var (
+	// ErrInvalidDraft 草稿数据未通过微信接口的字段校验
+	ErrInvalidDraft = errors.New("invalid draft payload")
+	// ErrNoPublishRecord 文章没有可用的发布记录
+	ErrNoPublishRecord = errors.New("no publish record")
+)
+
+// failedImageText 上传失败且没有替换图时，正文中代替图片的提示
+const failedImageText = "[图片加载失败]"
+
 // Publisher 发布器
 type Publisher struct {
 	cfg          *config.Config
@@ -25,8 +44,35 @@ type Publisher struct {
 	mdParser     *markdown.Parser
 	mdBeautifier *markdown.Beautifier
 	log          *logger.Logger
+	dryRun       bool
+	dryRunDir    string
+}
+
+// PublishResult 发布结果
+type PublishResult struct {
+	FilePath       string   `json:"file_path"`
+	Title          string   `json:"title,omitempty"`
+	MediaID        string   `json:"media_id,omitempty"`
+	SourceURL      string   `json:"source_url,omitempty"`
+	ImagesUploaded int      `json:"images_uploaded"`
+	CacheHit       bool     `json:"cache_hit"`
+	Unchanged      bool     `json:"unchanged,omitempty"`
+	PreviewURL     string   `json:"preview_url,omitempty"`
+	Warnings       []string `json:"warnings,omitempty"`
+	SeriesMediaIDs []string `json:"series_media_ids,omitempty"`
+	DryRun         bool     `json:"dry_run,omitempty"`
+	OutputPath     string   `json:"output_path,omitempty"` // 模拟运行时写出的HTML路径
+	// FailedImages 上传失败的正文图片及原因，这些图片在正文中保留原地址或被替换为占位图
+	FailedImages map[string]string `json:"failed_images,omitempty"`
+	TagID        string            `json:"tag_id,omitempty"`
+	UpdatedDraft bool              `json:"updated_draft,omitempty"` // 更新了上次发布的草稿而非新建
+	PublishID    string            `json:"publish_id,omitempty"`
+	ArticleURL   string            `json:"article_url,omitempty"`
 }
 
+// Resolver 返回文章所属公众号账号 (front matter 的 account) 的发布器
+type Resolver func(filePath string) (*Publisher, error)
+
 // NewPublisher 创建发布器
 func NewPublisher(
 	cfg *config.Config,
@@ -36,13 +82,16 @@ func NewPublisher(
 	log *logger.Logger,
 ) (*Publisher, error) {
 	mdParser := markdown.NewParser()
+	mdParser.SetLineBreakMode(markdown.LineBreakMode(cfg.Publish.LineBreaks))
 
-	// 尝试加载CSS模板，如果不存在使用默认
-	mdBeautifier, err := markdown.NewBeautifier("./assets")
+	// 加载主题，./assets 中的CSS模板覆盖主题中的同名模板
+	mdBeautifier, err := markdown.NewBeautifier("./assets", cfg.Publish.Theme)
 	if err != nil {
-		log.Warn("Failed to load CSS templates, using defaults", "error", err)
-		mdBeautifier, _ = markdown.NewBeautifier("")
+		return nil, fmt.Errorf("create beautifier: %w", err)
 	}
+	mdBeautifier.SetMaxFootnotes(cfg.Publish.MaxFootnotes)
+	mdBeautifier.SetEmbedFallback(cfg.Publish.EmbedFallback)
+	mdBeautifier.SetMinify(cfg.Publish.Minify)
 
 	return &Publisher{
 		cfg:          cfg,
@@ -55,85 +104,430 @@ func NewPublisher(
 	}, nil
 }
 
-// PublishArticle 发布单篇文章
-func (p *Publisher) PublishArticle(ctx context.Context, filePath string) error {
-	p.log.Info("Publishing article", "file", filePath)
+// SetDryRun 设置模拟运行：不调用微信接口 (图片使用原地址)，构建并校验草稿数据。
+// outputDir 不为空时将最终HTML和元数据写入 <outputDir>/<slug>/ 供预览
+func (p *Publisher) SetDryRun(dryRun bool, outputDir string) {
+	p.dryRun = dryRun
+	p.dryRunDir = outputDir
+}
+
+// draft 渲染完成、待提交的草稿
+type draft struct {
+	article     *markdown.Article
+	payload     wechat.Article
+	payloadHash string // 草稿数据摘要，判断重新渲染后是否有变化
+}
+
+// PublishArticle 发布单篇文章，已发布且未修改的文章跳过
+func (p *Publisher) PublishArticle(ctx context.Context, filePath string) (*PublishResult, error) {
+	result, err := p.publishArticle(ctx, filePath, false)
+	recordPublish(result, err)
+	return result, err
+}
+
+// RepublishArticle 忽略发布缓存重新发布文章，已发布或草稿数据未变化时也创建新草稿
+func (p *Publisher) RepublishArticle(ctx context.Context, filePath string) (*PublishResult, error) {
+	result, err := p.publishArticle(ctx, filePath, true)
+	recordPublish(result, err)
+	return result, err
+}
+
+// recordPublish 记录发布结果指标
+func recordPublish(result *PublishResult, err error) {
+	switch {
+	case err != nil:
+		metrics.PublishTotal.Inc("failure")
+	case result.CacheHit || result.Unchanged:
+		metrics.PublishTotal.Inc("skipped")
+	default:
+		metrics.PublishTotal.Inc("success")
+	}
+}
+
+// publishArticle 发布单篇文章，force 时跳过已发布检查
+func (p *Publisher) publishArticle(ctx context.Context, filePath string, force bool) (*PublishResult, error) {
+	// 同一次发布的令牌获取、图片上传和草稿提交日志共用一个 request_id
+	ctx = logger.EnsureRequestID(ctx)
+	p.log.InfoContext(ctx, "Publishing article", "file", filePath, "force", force)
+	result := &PublishResult{FilePath: filePath}
 
 	// 检查是否已处理
-	processed, err := p.cacheManager.IsFileProcessed(filePath)
+	if !force {
+		processed, err := p.IsPublished(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("check cache: %w", err)
+		}
+		if processed {
+			p.log.InfoContext(ctx, "Article already published, skipping", "file", filePath)
+			result.CacheHit = true
+			return result, nil
+		}
+	}
+
+	// 发布前钩子 (如 textlint)，失败时中止该文章的发布
+	if hook := p.cfg.Publish.PreHook; hook != "" {
+		if err := p.runHook(ctx, hook, filePath, nil); err != nil {
+			return nil, fmt.Errorf("pre hook: %w", err)
+		}
+	}
+
+	// 之前发布过时沿用其随机封面
+	var existingThumb string
+	if last, ok := p.cacheManager.GetPublishRecord(filePath); ok {
+		existingThumb = last.ThumbMediaID
+	}
+
+	d, err := p.prepareDraft(ctx, filePath, result, existingThumb)
 	if err != nil {
-		return fmt.Errorf("check cache: %w", err)
+		return nil, err
 	}
-	if processed {
-		p.log.Info("Article already published, skipping", "file", filePath)
-		return nil
+	article := d.article
+	wechatArticle := d.payload
+
+	// 记录源文件哈希，restyle 时据此判断内容是否变化
+	contentHash, err := cache.FileDigest(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("hash source file: %w", err)
+	}
+
+	// 草稿数据 (标题、摘要、作者、封面、正文等) 与上次发布完全相同时跳过草稿创建
+	if last, ok := p.cacheManager.GetPublishRecord(filePath); ok && !force && last.HTMLHash == d.payloadHash {
+		p.log.InfoContext(ctx, "Article draft unchanged, skipping draft", "file", filePath, "media_id", last.MediaID)
+		last.ContentHash = contentHash
+		last.TagID = article.TagID
+		if err := p.cacheManager.MarkFileProcessed(filePath, *last); err != nil {
+			p.log.WarnContext(ctx, "Failed to mark as processed", "error", err)
+		}
+		result.Title = article.Title
+		result.MediaID = last.MediaID
+		result.TagID = article.TagID
+		result.Unchanged = true
+		return result, nil
+	}
+
+	// 超长文章拆分为系列
+	if threshold := p.cfg.Publish.SplitThreshold; threshold > 0 {
+		if htmlLen := utf8.RuneCountInString(wechatArticle.Content); htmlLen > threshold {
+			seriesResult, err := p.publishSeries(ctx, filePath, article, wechatArticle, htmlLen, result)
+			if err != nil || seriesResult != nil {
+				return seriesResult, err
+			}
+		}
+	}
+
+	// 模拟运行只校验草稿数据
+	if p.dryRun {
+		if err := validateDraft(wechatArticle); err != nil {
+			return nil, err
+		}
+		p.log.InfoContext(ctx, "Dry run: draft payload is valid", "title", article.Title)
+		if p.dryRunDir != "" {
+			outPath, err := p.writeDryRun(filePath, article, wechatArticle)
+			if err != nil {
+				return nil, err
+			}
+			result.OutputPath = outPath
+		}
+		result.Title = article.Title
+		result.SourceURL = wechatArticle.ContentSourceURL
+		result.DryRun = true
+		return result, nil
+	}
+
+	// 该路径之前发布过时更新原草稿，避免编辑后产生重复草稿
+	var mediaID string
+	if p.cfg.Publish.UpdateExisting {
+		mediaID = p.updateExistingDraft(ctx, filePath, wechatArticle, result)
+	}
+
+	// 添加到草稿箱
+	if mediaID == "" {
+		p.log.InfoContext(ctx, "Adding to WeChat draft", "title", article.Title)
+		mediaID, err = p.wechatClient.AddDraft(ctx, []wechat.Article{wechatArticle})
+		if err != nil {
+			return nil, fmt.Errorf("add draft: %w", err)
+		}
+	}
+
+	p.log.InfoContext(ctx, "Successfully published", "media_id", mediaID)
+	result.Title = article.Title
+	result.MediaID = mediaID
+	result.SourceURL = wechatArticle.ContentSourceURL
+	result.TagID = article.TagID
+
+	// 自动提交发布，失败时草稿仍保留，只记录警告
+	if p.cfg.Publish.AutoPublish {
+		if err := p.freePublish(ctx, mediaID, result); err != nil {
+			p.log.WarnContext(ctx, "Failed to publish draft", "media_id", mediaID, "error", err)
+			result.Warnings = append(result.Warnings, fmt.Sprintf("auto publish: %v", err))
+		}
+	}
+
+	// 保存最终HTML用于归档和排查
+	if p.savesHTML() {
+		if err := p.saveHTML(filePath, wechatArticle.Content); err != nil {
+			p.log.WarnContext(ctx, "Failed to save rendered HTML", "error", err)
+		}
+	}
+
+	// 标记为已处理
+	if err := p.cacheManager.MarkFileProcessed(filePath, cache.FileRecord{
+		MediaID:      mediaID,
+		HTMLHash:     d.payloadHash,
+		ContentHash:  contentHash,
+		ThumbMediaID: wechatArticle.ThumbMediaID,
+		TagID:        article.TagID,
+	}); err != nil {
+		p.log.WarnContext(ctx, "Failed to mark as processed", "error", err)
+	}
+
+	// 发布后钩子，失败只记录警告
+	if hook := p.cfg.Publish.PostHook; hook != "" {
+		env := map[string]string{
+			"AWP_TITLE":    article.Title,
+			"AWP_MEDIA_ID": mediaID,
+		}
+		if err := p.runHook(ctx, hook, filePath, env); err != nil {
+			p.log.WarnContext(ctx, "Post hook failed", "error", err)
+			result.Warnings = append(result.Warnings, fmt.Sprintf("post hook: %v", err))
+		}
+	}
+
+	return result, nil
+}
+
+// updateExistingDraft 用新内容更新该路径上次发布的草稿，返回草稿 media_id。
+// 没有发布记录、上次为系列拆分或更新失败 (如草稿已被删除或发布) 时返回空字符串
+func (p *Publisher) updateExistingDraft(ctx context.Context, filePath string, payload wechat.Article, result *PublishResult) string {
+	record, ok := p.cacheManager.GetPublishRecord(filePath)
+	if !ok || record.MediaID == "" || len(record.SeriesMediaIDs) > 0 {
+		return ""
+	}
+
+	p.log.InfoContext(ctx, "Updating previously published draft", "title", payload.Title, "media_id", record.MediaID)
+	if err := p.wechatClient.UpdateDraft(ctx, record.MediaID, record.GroupIndex, payload); err != nil {
+		p.log.WarnContext(ctx, "Failed to update existing draft, creating a new one", "media_id", record.MediaID, "error", err)
+		result.Warnings = append(result.Warnings, fmt.Sprintf("update draft %s: %v, created a new draft", record.MediaID, err))
+		return ""
 	}
 
-	// 解析Markdown
+	result.UpdatedDraft = true
+	return record.MediaID
+}
+
+// loadArticle 解析文章并做发布前的检查：空文章报错、标题为空时使用文件名、结构检查
+func (p *Publisher) loadArticle(ctx context.Context, filePath string, result *PublishResult) (*markdown.Article, error) {
 	article, err := p.mdParser.ParseFile(filePath)
 	if err != nil {
-		return fmt.Errorf("parse markdown: %w", err)
+		return nil, fmt.Errorf("parse markdown: %w", err)
 	}
 
 	// [新增] 校验解析结果：防止因为文件编码/格式问题导致解析为空但未报错
 	if article.Title == "" && len(article.Content) == 0 {
-		return fmt.Errorf("parsed article is empty. Please check file encoding (use UTF-8 without BOM) and line endings: %s", filePath)
+		return nil, fmt.Errorf("parsed article is empty. Please check file encoding (use UTF-8 without BOM) and line endings: %s", filePath)
 	}
 	if article.Title == "" {
-		p.log.Warn("Article title is empty, using filename as fallback")
+		p.log.WarnContext(ctx, "Article title is empty, using filename as fallback")
 		filename := filepath.Base(filePath)
 		article.Title = strings.TrimSuffix(filename, filepath.Ext(filename))
+		result.Warnings = append(result.Warnings, "title is empty, using filename as title")
 	}
 
-	// 处理封面图片
-	images := article.Images
-	if len(images) == 0 || article.GenCover == "true" {
-		// 生成随机封面
-		seed := p.randomString(10)
-		coverURL := fmt.Sprintf("%s/%s/%s",
-			p.cfg.Image.PlaceholderService,
-			seed,
-			p.cfg.Image.DefaultCoverSize)
-		images = append([]string{coverURL}, images...)
+	// 检查未闭合的代码块、公式块等会破坏后续渲染的结构
+	if err := p.checkStructure(article, result); err != nil {
+		return nil, err
 	}
+	return article, nil
+}
 
-	// 并发上传图片
-	p.log.Info("Uploading images", "count", len(images))
-	imageMap, err := p.mediaManager.UploadImagesConcurrently(ctx, images, p.cfg.Publish.ConcurrentUploads)
-	if err != nil {
-		p.log.Warn("Some images failed to upload", "error", err)
-	}
+// renderedArticle 渲染后的正文及其附带信息
+type renderedArticle struct {
+	html      string
+	digest    string
+	sourceURL string
+}
 
+// renderArticle 把图片替换为 urlMap 中的地址后渲染最终 HTML (含文末二维码和主题排版)。
+// 发布与预览共用，保证预览与实际发布的内容一致
+func (p *Publisher) renderArticle(ctx context.Context, filePath string, article *markdown.Article, urlMap map[string]string, result *PublishResult) (*renderedArticle, error) {
 	// 更新内容中的图片URL
-	urlMap := make(map[string]string)
-	for originalURL, info := range imageMap {
-		urlMap[originalURL] = info.URL
-	}
 	article.Content = p.mdParser.UpdateImageURLs(article.Content, urlMap)
+	article.Captions = remapCaptions(article.Captions, urlMap)
 
 	// 转换为HTML
 	htmlContent := p.mdParser.ToHTML(article.Content)
 	if len(strings.TrimSpace(htmlContent)) == 0 {
-		return fmt.Errorf("HTML content is empty after conversion")
+		return nil, fmt.Errorf("HTML content is empty after conversion")
+	}
+
+	// 摘要：没有副标题时从正文提取，避免微信从原始 HTML 自动截取
+	digest := article.Subtitle
+	if digest == "" && p.cfg.Publish.AutoDigest {
+		digest = markdown.Digest(htmlContent, wechat.MaxDigestLength)
 	}
 
-	// 美化HTML
-	beautifiedHTML, err := p.mdBeautifier.Beautify(htmlContent)
+	// 生成文章链接，front matter 指定的原文地址优先
+	sourceURL, err := p.sourceURL(filePath, article)
+	if err != nil {
+		return nil, err
+	}
+
+	// 文末附加指向原文的二维码 (需要上传二维码图片，模拟运行时跳过)
+	if p.cfg.Publish.QRCode.Enabled && article.QRCode != "false" && sourceURL != "" && !p.dryRun {
+		footer, err := p.qrFooter(ctx, sourceURL)
+		if err != nil {
+			p.log.WarnContext(ctx, "Failed to add QR code footer", "error", err)
+			result.Warnings = append(result.Warnings, fmt.Sprintf("qr code footer: %v", err))
+		} else {
+			htmlContent += footer
+		}
+	}
+
+	// 美化HTML，front matter 可指定主题
+	beautifier, err := p.mdBeautifier.WithTheme(article.Theme)
+	if err != nil {
+		return nil, fmt.Errorf("article theme: %w", err)
+	}
+	beautifiedHTML, beautifyWarnings, err := beautifier.BeautifyWithWarnings(htmlContent, article)
 	if err != nil {
-		return fmt.Errorf("beautify html: %w", err)
+		return nil, fmt.Errorf("beautify html: %w", err)
 	}
+	result.Warnings = append(result.Warnings, beautifyWarnings...)
 
 	// 最终内容检查
 	if len(beautifiedHTML) == 0 {
-		return fmt.Errorf("final content is empty")
+		return nil, fmt.Errorf("final content is empty")
+	}
+
+	return &renderedArticle{
+		html:      beautifiedHTML,
+		digest:    digest,
+		sourceURL: sourceURL,
+	}, nil
+}
+
+// prepareDraft 解析文章、上传图片并渲染HTML，构建草稿数据。
+// existingThumb 为该文章已有草稿的封面，需要随机封面时沿用它，避免每次重新渲染都生成并上传新封面
+func (p *Publisher) prepareDraft(ctx context.Context, filePath string, result *PublishResult, existingThumb string) (*draft, error) {
+	article, err := p.loadArticle(ctx, filePath, result)
+	if err != nil {
+		return nil, err
+	}
+
+	// 预检查图片，避免上传到一半才发现缺失
+	if !p.cfg.Publish.SkipImageCheck {
+		if err := p.mediaManager.CheckImages(ctx, article.Images); err != nil {
+			return nil, fmt.Errorf("check images: %w", err)
+		}
+	}
+
+	// 处理封面图片
+	images := article.Images
+	generatedCover := len(images) == 0 || article.GenCover == "true"
+	reuseThumb := generatedCover && existingThumb != ""
+	if generatedCover && !reuseThumb {
+		// 生成随机封面
+		images = append([]string{p.generateCoverURL()}, images...)
+	}
+
+	// 并发上传图片，模拟运行时直接使用原地址
+	p.log.InfoContext(ctx, "Uploading images", "count", len(images))
+	var imageMap map[string]*media.ImageInfo
+	var failures map[string]error
+	if p.dryRun {
+		imageMap = stubImages(images)
+	} else if dir := p.cfg.Image.ManifestDir; dir != "" {
+		manifestPath, pathErr := manifestPath(dir, filePath)
+		if pathErr != nil {
+			return nil, pathErr
+		}
+		imageMap, failures, err = p.mediaManager.UploadImagesResumable(ctx, images, p.cfg.Publish.ConcurrentUploads, manifestPath)
+		if err != nil {
+			return nil, fmt.Errorf("upload images: %w", err)
+		}
+	} else {
+		imageMap, failures = p.mediaManager.UploadImagesConcurrently(ctx, images, p.cfg.Publish.ConcurrentUploads)
+	}
+
+	// 封面上传失败时中止，生成的随机封面则换一个重新生成；正文图片失败时记录原因后继续
+	// 沿用已有封面时 images[0] 只是正文图片
+	if !reuseThumb {
+		if err, ok := failures[images[0]]; ok {
+			if !generatedCover {
+				return nil, fmt.Errorf("upload cover %s: %w", images[0], err)
+			}
+			coverURL, info, genErr := p.regenerateCover(ctx)
+			if genErr != nil {
+				return nil, fmt.Errorf("upload cover %s: %w (regenerate: %v)", images[0], err, genErr)
+			}
+			p.log.WarnContext(ctx, "Generated cover failed to upload, using a new one", "cover", images[0], "error", err)
+			result.Warnings = append(result.Warnings, fmt.Sprintf("generated cover %s failed to upload (%v), regenerated", images[0], err))
+			delete(failures, images[0])
+			images[0] = coverURL
+			imageMap[coverURL] = info
+		}
+	}
+	result.ImagesUploaded = len(imageMap)
+	if len(failures) > 0 {
+		result.FailedImages = make(map[string]string, len(failures))
+		for _, image := range images {
+			if err, ok := failures[image]; ok {
+				p.log.WarnContext(ctx, "Image failed to upload", "image", image, "error", err)
+				result.FailedImages[image] = err.Error()
+				result.Warnings = append(result.Warnings, fmt.Sprintf("image %s failed to upload: %v", image, err))
+			}
+		}
+	}
+
+	// 上传失败的图片替换为占位图，避免正文出现失效链接
+	if p.cfg.Image.FallbackImage != "" && len(failures) > 0 {
+		p.substituteFallback(ctx, images, imageMap, result)
+	}
+
+	// 仍无法替换的图片从正文移除并留下提示，避免微信过滤外链图片后出现空白
+	missing := make(map[string]string)
+	for _, image := range images {
+		if _, failed := failures[image]; !failed {
+			continue
+		}
+		if _, ok := imageMap[image]; !ok {
+			missing[image] = failedImageText
+			result.Warnings = append(result.Warnings, fmt.Sprintf("image %s removed from content", image))
+		}
+	}
+	article.Content = p.mdParser.ReplaceImages(article.Content, missing)
+
+	// iframe 视频封面同样需要上传，失败时排版改用文字链接
+	article.Posters = p.uploadPosters(ctx, article, result)
+
+	urlMap := make(map[string]string)
+	for originalURL, info := range imageMap {
+		urlMap[originalURL] = info.URL
+	}
+	rendered, err := p.renderArticle(ctx, filePath, article, urlMap, result)
+	if err != nil {
+		return nil, err
 	}
 
 	// 准备文章数据
 	var thumbMediaID string
-	if len(images) > 0 {
+	if reuseThumb {
+		thumbMediaID = existingThumb
+	} else if len(images) > 0 {
 		if info, ok := imageMap[images[0]]; ok {
 			thumbMediaID = info.MediaID
 		}
+		// 正文图片使用外部存储时没有 media_id，封面需单独上传为微信素材；
+		// 配置了封面裁剪时同样单独上传裁剪后的封面
+		if p.dryRun {
+			thumbMediaID = dryRunMediaID
+		} else if thumbMediaID == "" || p.mediaManager.CropsCover() {
+			coverInfo, err := p.mediaManager.UploadCover(ctx, images[0])
+			if err != nil {
+				return nil, fmt.Errorf("upload cover: %w", err)
+			}
+			thumbMediaID = coverInfo.MediaID
+		}
 	}
 
 	// 获取作者
@@ -142,36 +536,318 @@ func (p *Publisher) PublishArticle(ctx context.Context, filePath string) error {
 		author = p.cfg.Blog.Author
 	}
 
-	// 生成文章链接
-	filename := filepath.Base(filePath)
-	link := strings.TrimSuffix(filename, filepath.Ext(filename))
-	sourceURL := p.cfg.Blog.BaseURL + link
-
-	// 创建微信文章
-	wechatArticle := wechat.Article{
+	payload := wechat.Article{
 		Title:            article.Title,
 		ThumbMediaID:     thumbMediaID,
 		Author:           author,
-		Digest:           article.Subtitle,
-		ShowCoverPic:     1,
-		Content:          beautifiedHTML,
-		ContentSourceURL: sourceURL,
+		Digest:           rendered.digest,
+		ShowCoverPic:     p.showCoverPic(article),
+		Content:          rendered.html,
+		ContentSourceURL: rendered.sourceURL,
 	}
+	return &draft{
+		article:     article,
+		payload:     payload,
+		payloadHash: payloadHash(payload, generatedCover),
+	}, nil
+}
 
-	// 添加到草稿箱
-	p.log.Info("Adding to WeChat draft", "title", article.Title)
-	mediaID, err := p.wechatClient.AddDraft(ctx, []wechat.Article{wechatArticle})
+// payloadHash 计算草稿数据摘要。随机生成的封面每次都不同，不计入摘要
+func payloadHash(payload wechat.Article, generatedCover bool) string {
+	if generatedCover {
+		payload.ThumbMediaID = ""
+	}
+	data, _ := json.Marshal(payload)
+	return fmt.Sprintf("%x", md5.Sum(data))
+}
+
+// uploadPosters 上传 publish.embed_fallback 替换 iframe 时使用的视频封面，返回原地址到上传后地址的映射。
+// 上传失败的封面不在映射中，对应视频改用文字链接
+func (p *Publisher) uploadPosters(ctx context.Context, article *markdown.Article, result *PublishResult) map[string]string {
+	if !p.cfg.Publish.EmbedFallback {
+		return nil
+	}
+	posters := markdown.VideoPosters(article.Content)
+	if len(posters) == 0 {
+		return nil
+	}
+
+	var uploaded map[string]*media.ImageInfo
+	var failures map[string]error
+	if p.dryRun {
+		uploaded = stubImages(posters)
+	} else {
+		uploaded, failures = p.mediaManager.UploadImagesConcurrently(ctx, posters, p.cfg.Publish.ConcurrentUploads)
+	}
+	for _, poster := range posters {
+		if err, ok := failures[poster]; ok {
+			p.log.WarnContext(ctx, "Video poster failed to upload, using a text link", "poster", poster, "error", err)
+			result.Warnings = append(result.Warnings, fmt.Sprintf("video poster %s failed to upload (%v), using a text link", poster, err))
+		}
+	}
+
+	urls := make(map[string]string, len(uploaded))
+	for poster, info := range uploaded {
+		urls[poster] = info.URL
+	}
+	return urls
+}
+
+// substituteFallback 为上传失败的图片上传并使用 image.fallback_image，每次替换产生一条警告
+func (p *Publisher) substituteFallback(ctx context.Context, images []string, imageMap map[string]*media.ImageInfo, result *PublishResult) {
+	var fallback *media.ImageInfo
+	for _, image := range images {
+		if _, ok := imageMap[image]; ok {
+			continue
+		}
+		if fallback == nil {
+			info, err := p.mediaManager.UploadImage(ctx, p.cfg.Image.FallbackImage)
+			if err != nil {
+				p.log.WarnContext(ctx, "Failed to upload fallback image", "error", err)
+				result.Warnings = append(result.Warnings, fmt.Sprintf("failed to upload fallback image: %v", err))
+				return
+			}
+			fallback = info
+		}
+		imageMap[image] = fallback
+		p.log.WarnContext(ctx, "Substituted fallback image", "image", image)
+		result.Warnings = append(result.Warnings, fmt.Sprintf("image %s replaced with fallback image", image))
+	}
+}
+
+// generateCoverURL 使用占位图服务生成随机封面地址
+func (p *Publisher) generateCoverURL() string {
+	return fmt.Sprintf("%s/%s/%s",
+		p.cfg.Image.PlaceholderService,
+		p.randomString(10),
+		p.cfg.Image.DefaultCoverSize)
+}
+
+// regenerateCover 换一个随机种子重新生成并上传封面
+func (p *Publisher) regenerateCover(ctx context.Context) (string, *media.ImageInfo, error) {
+	coverURL := p.generateCoverURL()
+	info, err := p.mediaManager.UploadImage(ctx, coverURL)
 	if err != nil {
-		return fmt.Errorf("add draft: %w", err)
+		return "", nil, err
 	}
+	return coverURL, info, nil
+}
 
-	p.log.Info("Successfully published", "media_id", mediaID)
+// qrFooter 生成原文链接二维码并上传，返回文末二维码区块
+func (p *Publisher) qrFooter(ctx context.Context, sourceURL string) (string, error) {
+	qr := p.cfg.Publish.QRCode
+	info, err := p.mediaManager.UploadQRCode(ctx, sourceURL, qr.Size)
+	if err != nil {
+		return "", err
+	}
+	return p.mdBeautifier.QRFooter(info.URL, qr.Size, qr.Caption), nil
+}
 
-	// 标记为已处理
-	if err := p.cacheManager.MarkFileProcessed(filePath); err != nil {
-		p.log.Warn("Failed to mark as processed", "error", err)
+// checkStructure 按 publish.structure_check 处理结构问题：warn 仅警告，fix 在末尾补全，error 中止发布
+func (p *Publisher) checkStructure(article *markdown.Article, result *PublishResult) error {
+	mode := p.cfg.Publish.StructureCheck
+	if mode == "" {
+		return nil
+	}
+
+	var issues []markdown.StructureIssue
+	if mode == "fix" {
+		article.Content, issues = markdown.FixStructure(article.Content)
+	} else {
+		issues = markdown.CheckStructure(article.Content)
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, len(issues))
+	for i, issue := range issues {
+		msgs[i] = issue.String()
+	}
+	if mode == "error" {
+		return fmt.Errorf("markdown structure: %s", strings.Join(msgs, "; "))
+	}
+
+	for _, msg := range msgs {
+		if mode == "fix" {
+			msg += " (auto-closed at end of article)"
+		}
+		p.log.Warn("Markdown structure issue", "issue", msg)
+		result.Warnings = append(result.Warnings, "markdown structure: "+msg)
+	}
+	return nil
+}
+
+// manifestPath 计算文章的上传清单路径
+func manifestPath(dir, filePath string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create manifest dir: %w", err)
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", fmt.Errorf("resolve path: %w", err)
+	}
+	return filepath.Join(dir, fmt.Sprintf("%x.json", md5.Sum([]byte(absPath)))), nil
+}
+
+// remapCaptions 图注以原始地址或文件名配置，上传后补充按新地址的映射
+func remapCaptions(captions map[string]string, urlMap map[string]string) map[string]string {
+	if len(captions) == 0 {
+		return captions
+	}
+
+	remapped := make(map[string]string, len(captions))
+	for key, caption := range captions {
+		remapped[key] = caption
+	}
+	for originalURL, newURL := range urlMap {
+		if caption, ok := captions[originalURL]; ok {
+			remapped[newURL] = caption
+		} else if caption, ok := captions[path.Base(originalURL)]; ok {
+			remapped[newURL] = caption
+		}
+	}
+	return remapped
+}
+
+// validateDraft 校验草稿数据，将所有问题合并为一个错误
+func validateDraft(a wechat.Article) error {
+	errs := wechat.ValidateArticle(a)
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidDraft, strings.Join(msgs, "; "))
+}
+
+// OrderArticles 确定多图文组内的文章顺序：指定了 order 的按 order 升序排在前面，
+// 其余按日期升序。第一篇为头条，其封面即为整组封面
+func (p *Publisher) OrderArticles(filePaths []string) ([]string, error) {
+	type entry struct {
+		path  string
+		order int
+		date  string
+	}
+
+	entries := make([]entry, 0, len(filePaths))
+	seen := make(map[int]string)
+	for _, filePath := range filePaths {
+		article, err := p.mdParser.ParseFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", filePath, err)
+		}
+		// 解析时无效的 order 被忽略，组内排序依赖它，在此报错
+		if _, err := markdown.ParseOrder(article.Meta["order"]); err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		if article.Order != 0 {
+			if other, ok := seen[article.Order]; ok {
+				return nil, fmt.Errorf("duplicate order %d in %s and %s", article.Order, other, filePath)
+			}
+			seen[article.Order] = filePath
+		}
+		entries = append(entries, entry{path: filePath, order: article.Order, date: article.Date})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if (a.order != 0) != (b.order != 0) {
+			return a.order != 0
+		}
+		if a.order != b.order {
+			return a.order < b.order
+		}
+		return a.date < b.date
+	})
+
+	ordered := make([]string, len(entries))
+	for i, e := range entries {
+		ordered[i] = e.path
+	}
+	return ordered, nil
+}
+
+// PreviewToUser 将已创建的草稿预览发送给测试用户，openid 必须在 wechat.test_openids 中
+func (p *Publisher) PreviewToUser(ctx context.Context, mediaID, openID string) error {
+	allowed := false
+	for _, id := range p.cfg.WeChat.TestOpenIDs {
+		if id == openID {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("openid %s is not listed in wechat.test_openids", openID)
+	}
+
+	p.log.InfoContext(ctx, "Sending preview to test user", "media_id", mediaID, "openid", openID)
+	if err := p.wechatClient.PreviewToUser(ctx, openID, mediaID); err != nil {
+		return fmt.Errorf("preview to user: %w", err)
+	}
+	return nil
+}
+
+// sourceURL 计算阅读原文链接
+func (p *Publisher) sourceURL(filePath string, article *markdown.Article) (string, error) {
+	if article.Canonical != "" {
+		u, err := url.Parse(article.Canonical)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("invalid canonical_url: %q", article.Canonical)
+		}
+		return article.Canonical, nil
+	}
+
+	filename := filepath.Base(filePath)
+	link := strings.TrimSuffix(filename, filepath.Ext(filename))
+	return p.cfg.Blog.BaseURL + link, nil
+}
+
+// showCoverPic 计算是否在正文中显示封面，front matter 优先于配置
+func (p *Publisher) showCoverPic(article *markdown.Article) int {
+	show := true
+	if p.cfg.Publish.ShowCoverPic != nil {
+		show = *p.cfg.Publish.ShowCoverPic
+	}
+
+	switch strings.ToLower(article.ShowCover) {
+	case "true", "1", "yes":
+		show = true
+	case "false", "0", "no":
+		show = false
+	}
+
+	if show {
+		return 1
+	}
+	return 0
+}
+
+// savesHTML 是否配置了保存最终HTML
+func (p *Publisher) savesHTML() bool {
+	return p.cfg.Publish.HTMLBesideSource || p.cfg.Publish.SaveHTMLDir != ""
+}
+
+// saveHTML 将美化后的HTML写入 <slug>.wx.html
+func (p *Publisher) saveHTML(filePath, content string) error {
+	dir := p.cfg.Publish.SaveHTMLDir
+	if p.cfg.Publish.HTMLBesideSource {
+		dir = filepath.Dir(filePath)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+
+	filename := filepath.Base(filePath)
+	slug := strings.TrimSuffix(filename, filepath.Ext(filename))
+	outPath := filepath.Join(dir, slug+".wx.html")
+	if err := os.WriteFile(outPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("write html: %w", err)
 	}
 
+	p.log.Info("Saved rendered HTML", "path", outPath)
 	return nil
 }
 
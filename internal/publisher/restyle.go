@@ -0,0 +1,89 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+
+	"auto-wx-post/internal/cache"
+)
+
+// RestyleResult 单篇文章的重新排版结果
+type RestyleResult struct {
+	FilePath string `json:"file_path"`
+	MediaID  string `json:"media_id,omitempty"`
+	Restyled bool   `json:"restyled"`
+	Skipped  string `json:"skipped,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Restyle 使用当前模板重新渲染已发布的草稿并原地更新。
+// 只处理源文件未变化的文章，内容有变化的需要重新发布
+func (p *Publisher) Restyle(ctx context.Context) []RestyleResult {
+	records := p.cacheManager.PublishRecords()
+	results := make([]RestyleResult, 0, len(records))
+
+	for _, record := range records {
+		r := RestyleResult{FilePath: record.FilePath, MediaID: record.MediaID}
+		skipped, err := p.restyleRecord(ctx, record)
+		switch {
+		case err != nil:
+			p.log.ErrorContext(ctx, "Failed to restyle draft", "file", record.FilePath, "error", err)
+			r.Error = err.Error()
+		case skipped != "":
+			p.log.InfoContext(ctx, "Skipping restyle", "file", record.FilePath, "reason", skipped)
+			r.Skipped = skipped
+		default:
+			r.Restyled = true
+		}
+		results = append(results, r)
+	}
+
+	return results
+}
+
+// restyleRecord 重新排版单条发布记录，返回跳过原因
+func (p *Publisher) restyleRecord(ctx context.Context, record *cache.FileRecord) (string, error) {
+	switch {
+	case record.MediaID == "":
+		return "no draft media_id recorded", nil
+	case len(record.SeriesMediaIDs) > 0:
+		return "series drafts are not restyled", nil
+	case record.ContentHash == "":
+		return "no content hash recorded, republish once to enable restyle", nil
+	}
+
+	contentHash, err := cache.FileDigest(record.FilePath)
+	if err != nil {
+		return "", fmt.Errorf("hash source file: %w", err)
+	}
+	if contentHash != record.ContentHash {
+		return "source changed since publish, republish instead", nil
+	}
+
+	d, err := p.prepareDraft(ctx, record.FilePath, &PublishResult{FilePath: record.FilePath}, record.ThumbMediaID)
+	if err != nil {
+		return "", err
+	}
+	if d.payloadHash == record.HTMLHash {
+		return "already up to date", nil
+	}
+
+	if p.dryRun {
+		if err := validateDraft(d.payload); err != nil {
+			return "", err
+		}
+		return "dry run", nil
+	}
+
+	p.log.InfoContext(ctx, "Updating draft with current theme", "file", record.FilePath, "media_id", record.MediaID)
+	if err := p.wechatClient.UpdateDraft(ctx, record.MediaID, record.GroupIndex, d.payload); err != nil {
+		return "", fmt.Errorf("update draft: %w", err)
+	}
+
+	record.HTMLHash = d.payloadHash
+	record.ThumbMediaID = d.payload.ThumbMediaID
+	if err := p.cacheManager.MarkFileProcessed(record.FilePath, *record); err != nil {
+		p.log.WarnContext(ctx, "Failed to mark as processed", "error", err)
+	}
+	return "", nil
+}
@@ -0,0 +1,158 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"auto-wx-post/internal/cache"
+	"auto-wx-post/internal/markdown"
+	"auto-wx-post/internal/wechat"
+)
+
+// publishSeries 将超长文章在顶级标题处拆分为系列，每部分单独创建草稿
+func (p *Publisher) publishSeries(
+	ctx context.Context,
+	filePath string,
+	article *markdown.Article,
+	base wechat.Article,
+	htmlLen int,
+	result *PublishResult,
+) (*PublishResult, error) {
+	threshold := p.cfg.Publish.SplitThreshold
+
+	// 按 HTML 与 Markdown 的长度比例估算每部分的 Markdown 长度
+	mdLen := len(article.Content)
+	target := mdLen * threshold / htmlLen
+	parts := markdown.SplitAtHeadings(article.Content, target)
+	if len(parts) < 2 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"content exceeds split_threshold (%d) but has no headings to split at", threshold))
+		return nil, nil
+	}
+
+	p.log.InfoContext(ctx, "Splitting long article into series", "file", filePath, "parts", len(parts))
+
+	titles := make([]string, len(parts))
+	for i := range parts {
+		titles[i] = article.Title + seriesSuffix(i, len(parts))
+	}
+
+	beautifier, err := p.mdBeautifier.WithTheme(article.Theme)
+	if err != nil {
+		return nil, fmt.Errorf("article theme: %w", err)
+	}
+
+	// 先渲染全部部分，渲染失败时不会留下部分草稿
+	payloads := make([]wechat.Article, len(parts))
+	for i, part := range parts {
+		part += seriesNavigation(titles, i, base.ContentSourceURL != "")
+
+		htmlContent := p.mdParser.ToHTML(part)
+		beautifiedHTML, warnings, err := beautifier.BeautifyWithWarnings(htmlContent, article)
+		if err != nil {
+			return nil, fmt.Errorf("beautify part %d: %w", i+1, err)
+		}
+		result.Warnings = append(result.Warnings, warnings...)
+		if n := utf8.RuneCountInString(beautifiedHTML); n > threshold {
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"part %d is still %d characters after splitting", i+1, n))
+		}
+
+		wechatArticle := base
+		wechatArticle.Title = titles[i]
+		wechatArticle.Content = beautifiedHTML
+		if article.Subtitle == "" && p.cfg.Publish.AutoDigest {
+			wechatArticle.Digest = markdown.Digest(htmlContent, wechat.MaxDigestLength)
+		}
+
+		if err := validateDraft(wechatArticle); err != nil {
+			return nil, fmt.Errorf("part %d: %w", i+1, err)
+		}
+		payloads[i] = wechatArticle
+	}
+
+	if p.dryRun {
+		p.log.InfoContext(ctx, "Dry run: series payload is valid", "title", article.Title, "parts", len(parts))
+		result.Title = article.Title
+		result.SourceURL = base.ContentSourceURL
+		result.DryRun = true
+		return result, nil
+	}
+
+	// 任一部分创建失败时删除本次已创建的部分，避免重新运行时产生重复草稿
+	mediaIDs := make([]string, 0, len(parts))
+	for i, payload := range payloads {
+		p.log.InfoContext(ctx, "Adding series part to WeChat draft", "title", payload.Title)
+		mediaID, err := p.wechatClient.AddDraft(ctx, []wechat.Article{payload})
+		if err != nil {
+			err = fmt.Errorf("add draft for part %d: %w", i+1, err)
+			if left := p.discardDrafts(ctx, mediaIDs); len(left) > 0 {
+				return nil, fmt.Errorf("%w (failed to delete drafts of earlier parts: %s)", err, strings.Join(left, ", "))
+			}
+			return nil, err
+		}
+		mediaIDs = append(mediaIDs, mediaID)
+	}
+
+	p.log.InfoContext(ctx, "Successfully published series", "media_ids", mediaIDs)
+	result.Title = article.Title
+	result.MediaID = mediaIDs[0]
+	result.SeriesMediaIDs = mediaIDs
+	result.TagID = article.TagID
+	result.SourceURL = base.ContentSourceURL
+
+	if err := p.cacheManager.MarkFileProcessed(filePath, cache.FileRecord{
+		MediaID:        mediaIDs[0],
+		SeriesMediaIDs: mediaIDs,
+		ThumbMediaID:   base.ThumbMediaID,
+		TagID:          article.TagID,
+	}); err != nil {
+		p.log.WarnContext(ctx, "Failed to mark as processed", "error", err)
+	}
+
+	return result, nil
+}
+
+// seriesSuffix 返回系列标题后缀：两篇为上/下，三篇为上/中/下，更多时使用序号
+func seriesSuffix(i, n int) string {
+	switch n {
+	case 2:
+		return []string{"（上）", "（下）"}[i]
+	case 3:
+		return []string{"（上）", "（中）", "（下）"}[i]
+	default:
+		return fmt.Sprintf("（%d/%d）", i+1, n)
+	}
+}
+
+// discardDrafts 删除已创建的草稿，返回删除失败的 media_id
+func (p *Publisher) discardDrafts(ctx context.Context, mediaIDs []string) []string {
+	var left []string
+	for _, mediaID := range mediaIDs {
+		if err := p.wechatClient.DeleteDraft(ctx, mediaID); err != nil {
+			p.log.WarnContext(ctx, "Failed to delete series draft", "media_id", mediaID, "error", err)
+			left = append(left, mediaID)
+			continue
+		}
+		p.log.InfoContext(ctx, "Deleted series draft after failure", "media_id", mediaID)
+	}
+	return left
+}
+
+// seriesNavigation 生成系列各部分之间的互相引用。草稿在发布前没有可访问的地址，
+// 其他部分只能以标题引用；有原文地址时 (每部分的阅读原文链接) 提示通过阅读原文查看完整文章
+func seriesNavigation(titles []string, i int, hasSourceURL bool) string {
+	nav := fmt.Sprintf("\n\n---\n\n> 本文为系列第 %d/%d 篇", i+1, len(titles))
+	if i > 0 {
+		nav += fmt.Sprintf("，上一篇：《%s》", titles[i-1])
+	}
+	if i < len(titles)-1 {
+		nav += fmt.Sprintf("，下一篇：《%s》", titles[i+1])
+	}
+	if hasSourceURL {
+		nav += "，点击「阅读原文」查看完整文章"
+	}
+	return nav + "\n"
+}
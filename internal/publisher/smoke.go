@@ -0,0 +1,123 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+
+	"auto-wx-post/internal/wechat"
+)
+
+// SmokeStep 冒烟测试的单个步骤
+type SmokeStep struct {
+	Name       string `json:"name"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SmokeResult 冒烟测试结果
+type SmokeResult struct {
+	OK         bool        `json:"ok"`
+	DurationMs int64       `json:"duration_ms"`
+	Steps      []SmokeStep `json:"steps"`
+}
+
+// SmokeTest 端到端验证微信接口：获取 token、上传封面、创建草稿，随后删除草稿和封面。
+// 不读取文章、不读写缓存
+func (p *Publisher) SmokeTest(ctx context.Context) *SmokeResult {
+	start := time.Now()
+	result := &SmokeResult{OK: true}
+
+	step := func(name string, fn func() error) bool {
+		stepStart := time.Now()
+		err := fn()
+		s := SmokeStep{Name: name, DurationMs: time.Since(stepStart).Milliseconds()}
+		if err != nil {
+			s.Error = err.Error()
+			result.OK = false
+		}
+		result.Steps = append(result.Steps, s)
+		return err == nil
+	}
+	defer func() {
+		result.DurationMs = time.Since(start).Milliseconds()
+	}()
+
+	if !step("get_access_token", func() error {
+		_, err := p.wechatClient.GetAccessToken(ctx)
+		return err
+	}) {
+		return result
+	}
+
+	var thumbMediaID string
+	if !step("upload_cover", func() error {
+		coverPath, err := writeSmokeCover(p.cfg.Image.TempDir)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(coverPath)
+
+		upload, err := p.wechatClient.UploadPermanentMedia(ctx, wechat.MediaTypeImage, coverPath)
+		if err != nil {
+			return err
+		}
+		thumbMediaID = upload.MediaID
+		return nil
+	}) {
+		return result
+	}
+	defer step("delete_cover", func() error {
+		return p.wechatClient.DeleteMaterial(ctx, thumbMediaID)
+	})
+
+	var mediaID string
+	if !step("add_draft", func() error {
+		var err error
+		mediaID, err = p.wechatClient.AddDraft(ctx, []wechat.Article{{
+			Title:        "auto-wx-post smoke test",
+			ThumbMediaID: thumbMediaID,
+			Content:      fmt.Sprintf("<p>smoke test %s</p>", start.Format(time.RFC3339)),
+		}})
+		return err
+	}) {
+		return result
+	}
+
+	step("delete_draft", func() error {
+		return p.wechatClient.DeleteDraft(ctx, mediaID)
+	})
+
+	return result
+}
+
+// writeSmokeCover 生成一张纯色PNG作为测试封面
+func writeSmokeCover(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			img.Set(x, y, color.RGBA{R: 7, G: 193, B: 96, A: 255})
+		}
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("smoke-%d.png", time.Now().UnixNano()))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, img); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,29 @@
+package publisher
+
+import (
+	"fmt"
+	"time"
+
+	"auto-wx-post/internal/dates"
+)
+
+// IsPublished 判断文章是否无需重新发布。front matter 带 updated 字段且有发布记录时，
+// 以 updated 是否晚于上次发布时间为准；否则按缓存键策略 (默认内容哈希) 判断
+func (p *Publisher) IsPublished(filePath string) (bool, error) {
+	record, ok := p.cacheManager.GetPublishRecord(filePath)
+	if ok && !record.PublishedAt.IsZero() {
+		article, err := p.mdParser.ParseFile(filePath)
+		if err != nil {
+			return false, fmt.Errorf("parse markdown: %w", err)
+		}
+		if article.Updated != "" {
+			updated, err := dates.ParseTimestamp(article.Updated, time.Local)
+			if err == nil {
+				return !updated.After(record.PublishedAt), nil
+			}
+			p.log.Warn("Invalid updated field, falling back to cache key", "file", filePath, "error", err)
+		}
+	}
+
+	return p.cacheManager.IsFileProcessed(filePath)
+}
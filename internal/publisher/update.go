@@ -0,0 +1,80 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+
+	"auto-wx-post/internal/cache"
+	"auto-wx-post/internal/logger"
+)
+
+// UpdateDraft 重新渲染文章并原地更新已有草稿中的指定位置，避免反复修改时产生重复草稿。
+// mediaID 为空时使用该文件发布记录中的草稿和位置；index 为负数时同样取发布记录中的位置 (没有记录时为 0)
+func (p *Publisher) UpdateDraft(ctx context.Context, filePath, mediaID string, index int) (*PublishResult, error) {
+	ctx = logger.EnsureRequestID(ctx)
+	record, hasRecord := p.cacheManager.GetPublishRecord(filePath)
+	if mediaID == "" {
+		if !hasRecord || record.MediaID == "" {
+			return nil, fmt.Errorf("no media_id given and %w for %s", ErrNoPublishRecord, filePath)
+		}
+		if len(record.SeriesMediaIDs) > 0 {
+			return nil, fmt.Errorf("%s was published as a series of %d drafts, give the media_id to update", filePath, len(record.SeriesMediaIDs))
+		}
+		mediaID = record.MediaID
+	}
+	if index < 0 {
+		index = 0
+		if hasRecord && record.MediaID == mediaID {
+			index = record.GroupIndex
+		}
+	}
+
+	// 更新的是该文件自己的草稿时沿用其随机封面
+	var existingThumb string
+	if hasRecord && record.MediaID == mediaID {
+		existingThumb = record.ThumbMediaID
+	}
+
+	result := &PublishResult{FilePath: filePath}
+	d, err := p.prepareDraft(ctx, filePath, result, existingThumb)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDraft(d.payload); err != nil {
+		return nil, err
+	}
+
+	result.Title = d.article.Title
+	result.MediaID = mediaID
+	result.SourceURL = d.payload.ContentSourceURL
+	result.TagID = d.article.TagID
+
+	if p.dryRun {
+		p.log.InfoContext(ctx, "Dry run: draft update payload is valid", "title", d.article.Title, "media_id", mediaID, "index", index)
+		result.DryRun = true
+		return result, nil
+	}
+
+	p.log.InfoContext(ctx, "Updating draft", "title", d.article.Title, "media_id", mediaID, "index", index)
+	if err := p.wechatClient.UpdateDraft(ctx, mediaID, index, d.payload); err != nil {
+		return nil, fmt.Errorf("update draft: %w", err)
+	}
+	result.UpdatedDraft = true
+
+	contentHash, err := cache.FileDigest(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("hash source file: %w", err)
+	}
+	if err := p.cacheManager.MarkFileProcessed(filePath, cache.FileRecord{
+		MediaID:      mediaID,
+		HTMLHash:     d.payloadHash,
+		ContentHash:  contentHash,
+		ThumbMediaID: d.payload.ThumbMediaID,
+		TagID:        d.article.TagID,
+		GroupIndex:   index,
+	}); err != nil {
+		p.log.WarnContext(ctx, "Failed to mark as processed", "error", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"auto-wx-post/internal/cache"
+)
+
+// JobStatus 定时任务状态
+type JobStatus string
+
+const (
+	// JobPending 等待到达发布时间
+	JobPending JobStatus = "pending"
+	// JobDone 已发布 (或发现已发布过)
+	JobDone JobStatus = "done"
+	// JobFailed 多次发布失败，不再重试
+	JobFailed JobStatus = "failed"
+)
+
+// Job 定时发布任务，以文件绝对路径为键
+type Job struct {
+	FilePath  string    `json:"file_path"`
+	PublishAt time.Time `json:"publish_at"`
+	Status    JobStatus `json:"status"`
+	Attempts  int       `json:"attempts,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	MediaID   string    `json:"media_id,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Queue 持久化的定时发布队列 (线程安全)，与缓存相同以 JSON 数组保存，每次修改后原子写入
+type Queue struct {
+	jobs      map[string]*Job
+	storePath string
+	mutex     sync.RWMutex
+}
+
+// NewQueue 打开队列文件，不存在时创建空队列
+func NewQueue(storePath string) (*Queue, error) {
+	if dir := filepath.Dir(storePath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create queue dir: %w", err)
+		}
+	}
+
+	q := &Queue{
+		jobs:      make(map[string]*Job),
+		storePath: storePath,
+	}
+	if err := q.load(); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("load queue: %w", err)
+	}
+	return q, nil
+}
+
+// Enqueue 加入或更新任务。发布时间变化的任务重新变为待发布，其余状态保持不变。
+// 返回是否新增或修改了任务
+func (q *Queue) Enqueue(filePath string, publishAt time.Time) (bool, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if job, ok := q.jobs[filePath]; ok {
+		if job.PublishAt.Equal(publishAt) {
+			return false, nil
+		}
+		job.PublishAt = publishAt
+		job.Status = JobPending
+		job.Attempts = 0
+		job.Error = ""
+		job.UpdatedAt = time.Now()
+		return true, q.save()
+	}
+
+	q.jobs[filePath] = &Job{
+		FilePath:  filePath,
+		PublishAt: publishAt,
+		Status:    JobPending,
+		UpdatedAt: time.Now(),
+	}
+	return true, q.save()
+}
+
+// Get 获取任务副本
+func (q *Queue) Get(filePath string) (Job, bool) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	job, ok := q.jobs[filePath]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Update 修改任务并保存，任务不存在时忽略
+func (q *Queue) Update(filePath string, fn func(job *Job)) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	job, ok := q.jobs[filePath]
+	if !ok {
+		return nil
+	}
+	fn(job)
+	job.UpdatedAt = time.Now()
+	return q.save()
+}
+
+// Due 返回发布时间不晚于 now 的待发布任务，按发布时间排序
+func (q *Queue) Due(now time.Time) []Job {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	var due []Job
+	for _, job := range q.jobs {
+		if job.Status == JobPending && !job.PublishAt.After(now) {
+			due = append(due, *job)
+		}
+	}
+	sortJobs(due)
+	return due
+}
+
+// Jobs 返回全部任务的副本，按发布时间排序
+func (q *Queue) Jobs() []Job {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, *job)
+	}
+	sortJobs(jobs)
+	return jobs
+}
+
+// sortJobs 按发布时间、文件路径排序
+func sortJobs(jobs []Job) {
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].PublishAt.Equal(jobs[j].PublishAt) {
+			return jobs[i].PublishAt.Before(jobs[j].PublishAt)
+		}
+		return jobs[i].FilePath < jobs[j].FilePath
+	})
+}
+
+// load 从文件加载队列
+func (q *Queue) load() error {
+	data, err := os.ReadFile(q.storePath)
+	if err != nil {
+		return err
+	}
+
+	var jobs []*Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return fmt.Errorf("unmarshal queue: %w", err)
+	}
+	for _, job := range jobs {
+		q.jobs[job.FilePath] = job
+	}
+	return nil
+}
+
+// save 保存队列到文件
+func (q *Queue) save() error {
+	jobs := make([]*Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].FilePath < jobs[j].FilePath })
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal queue: %w", err)
+	}
+	return cache.WriteFileAtomic(q.storePath, data)
+}
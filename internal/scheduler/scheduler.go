@@ -0,0 +1,186 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"auto-wx-post/internal/config"
+	"auto-wx-post/internal/dates"
+	"auto-wx-post/internal/logger"
+	"auto-wx-post/internal/markdown"
+	"auto-wx-post/internal/publisher"
+)
+
+// maxAttempts 单个任务的最大发布次数，超过后标记为失败
+const maxAttempts = 3
+
+// Publisher 发布文章，多账号时由调用方按文章 front matter 选择账号
+type Publisher interface {
+	IsPublished(filePath string) (bool, error)
+	PublishArticle(ctx context.Context, filePath string) (*publisher.PublishResult, error)
+}
+
+// Scheduler 定时发布调度器。定期扫描文章目录，将设置了 publish_at 的文章加入持久化队列，
+// 到达发布时间后发布。队列保存在文件中，重启后错过的任务会立即发布
+type Scheduler struct {
+	queue      *Queue
+	pub        Publisher
+	parser     *markdown.Parser
+	sourcePath string
+	interval   time.Duration
+	pacer      *publisher.Pacer
+	log        *logger.Logger
+}
+
+// New 创建调度器
+func New(cfg *config.Config, queue *Queue, pub Publisher, log *logger.Logger) *Scheduler {
+	return &Scheduler{
+		queue:      queue,
+		pub:        pub,
+		parser:     markdown.NewParser(),
+		sourcePath: cfg.Blog.SourcePath,
+		interval:   time.Duration(cfg.Schedule.PollInterval) * time.Second,
+		pacer: publisher.NewPacer(
+			time.Duration(cfg.Publish.Interval)*time.Second,
+			time.Duration(cfg.Publish.RateLimitBackoff)*time.Second),
+		log: log,
+	}
+}
+
+// Run 立即执行一次扫描和发布，之后每个轮询间隔重复，直到 ctx 取消
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick 重新扫描文章目录 (publish_at 可能被修改)，然后发布所有到期任务
+func (s *Scheduler) tick(ctx context.Context) {
+	if added, err := s.Scan(); err != nil {
+		s.log.Warn("Failed to scan scheduled articles", "error", err)
+	} else if added > 0 {
+		s.log.Info("Scheduled articles updated", "count", added)
+	}
+
+	var lastErr error
+	for i, job := range s.queue.Due(time.Now()) {
+		if i > 0 {
+			// 与批量发布相同的间隔，遇到限流时退避
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.pacer.Next(lastErr)):
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		lastErr = s.fire(ctx, job)
+	}
+}
+
+// Scan 扫描文章目录，将设置了 publish_at 且尚未发布的文章加入队列，返回新增或修改的任务数
+func (s *Scheduler) Scan() (int, error) {
+	changed := 0
+	err := filepath.Walk(s.sourcePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".md" {
+			return nil
+		}
+
+		article, err := s.parser.ParseFile(path)
+		if err != nil || article.PublishAt == "" {
+			return nil
+		}
+		publishAt, err := dates.ParseTimestamp(article.PublishAt, time.Local)
+		if err != nil {
+			s.log.Warn("Invalid publish_at, article not scheduled", "file", path, "error", err)
+			return nil
+		}
+
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("resolve path: %w", err)
+		}
+
+		// 不在队列中且已发布过的文章不再加入
+		if _, queued := s.queue.Get(absPath); !queued {
+			if published, _ := s.pub.IsPublished(absPath); published {
+				return nil
+			}
+		}
+
+		updated, err := s.queue.Enqueue(absPath, publishAt)
+		if err != nil {
+			return err
+		}
+		if updated {
+			s.log.Info("Article scheduled", "file", absPath, "publish_at", publishAt.Format(time.RFC3339))
+			changed++
+		}
+		return nil
+	})
+	return changed, err
+}
+
+// fire 发布到期任务并记录结果，返回发布错误。失败的任务在下次轮询时重试
+func (s *Scheduler) fire(ctx context.Context, job Job) error {
+	if _, err := os.Stat(job.FilePath); os.IsNotExist(err) {
+		s.log.Warn("Scheduled article no longer exists", "file", job.FilePath)
+		s.update(job.FilePath, func(j *Job) {
+			j.Status = JobFailed
+			j.Error = "file not found"
+		})
+		return nil
+	}
+
+	if published, _ := s.pub.IsPublished(job.FilePath); published {
+		s.log.Info("Scheduled article already published, skipping", "file", job.FilePath)
+		s.update(job.FilePath, func(j *Job) { j.Status = JobDone })
+		return nil
+	}
+
+	s.log.Info("Publishing scheduled article", "file", job.FilePath, "publish_at", job.PublishAt.Format(time.RFC3339))
+	result, err := s.pub.PublishArticle(ctx, job.FilePath)
+	s.update(job.FilePath, func(j *Job) {
+		j.Attempts++
+		if err != nil {
+			j.Error = err.Error()
+			if j.Attempts >= maxAttempts {
+				j.Status = JobFailed
+			}
+			return
+		}
+		j.Status = JobDone
+		j.Error = ""
+		j.MediaID = result.MediaID
+	})
+
+	if err != nil {
+		s.log.Error("Failed to publish scheduled article", "file", job.FilePath, "attempt", job.Attempts+1, "error", err)
+		return err
+	}
+	s.log.Info("Scheduled article published", "file", job.FilePath, "media_id", result.MediaID)
+	return nil
+}
+
+// update 修改任务状态，保存失败时只记录日志
+func (s *Scheduler) update(filePath string, fn func(job *Job)) {
+	if err := s.queue.Update(filePath, fn); err != nil {
+		s.log.Error("Failed to save schedule queue", "error", err)
+	}
+}
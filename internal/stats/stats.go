@@ -0,0 +1,79 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"auto-wx-post/internal/catalog"
+)
+
+// Summary 内容仓库统计
+type Summary struct {
+	TotalArticles   int            `json:"total_articles"`
+	Published       int            `json:"published"`
+	Unpublished     int            `json:"unpublished"`
+	ArticlesByMonth map[string]int `json:"articles_by_month"`
+	TotalImages     int            `json:"total_images"`
+	AverageLength   int            `json:"average_length"`
+}
+
+// Collect 使用与文章列表相同的查找器遍历文章目录，结合发布记录生成统计
+func Collect(finder *catalog.Finder) (*Summary, error) {
+	articles, err := finder.Find(catalog.Filter{ShowPublished: true})
+	if err != nil {
+		return nil, fmt.Errorf("walk source: %w", err)
+	}
+
+	summary := &Summary{
+		TotalArticles:   len(articles),
+		ArticlesByMonth: make(map[string]int),
+	}
+	totalLength := 0
+	for _, article := range articles {
+		summary.TotalImages += article.Images
+		totalLength += article.Length
+
+		month := "unknown"
+		if len(article.Date) >= 7 {
+			month = article.Date[:7]
+		}
+		summary.ArticlesByMonth[month]++
+
+		if article.Published {
+			summary.Published++
+		} else {
+			summary.Unpublished++
+		}
+	}
+
+	if summary.TotalArticles > 0 {
+		summary.AverageLength = totalLength / summary.TotalArticles
+	}
+
+	return summary, nil
+}
+
+// Text 格式化为文本输出
+func (s *Summary) Text() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "文章总数: %d\n", s.TotalArticles)
+	fmt.Fprintf(&sb, "已发布: %d\n", s.Published)
+	fmt.Fprintf(&sb, "未发布: %d\n", s.Unpublished)
+	fmt.Fprintf(&sb, "图片总数: %d\n", s.TotalImages)
+	fmt.Fprintf(&sb, "平均长度: %d 字\n", s.AverageLength)
+
+	months := make([]string, 0, len(s.ArticlesByMonth))
+	for month := range s.ArticlesByMonth {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	sb.WriteString("按月分布:\n")
+	for _, month := range months {
+		fmt.Fprintf(&sb, "  %s: %d\n", month, s.ArticlesByMonth[month])
+	}
+
+	return sb.String()
+}
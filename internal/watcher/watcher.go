@@ -0,0 +1,211 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"auto-wx-post/internal/dates"
+	"auto-wx-post/internal/logger"
+	"auto-wx-post/internal/markdown"
+	"auto-wx-post/internal/publisher"
+)
+
+// DefaultDebounce 文件最后一次变化后等待的时间，编辑器保存时通常连续触发多个事件
+const DefaultDebounce = 2 * time.Second
+
+// Publisher 发布文章，多账号时由调用方按文章 front matter 选择账号
+type Publisher interface {
+	IsPublished(filePath string) (bool, error)
+	PublishArticle(ctx context.Context, filePath string) (*publisher.PublishResult, error)
+}
+
+// Watcher 监听文章目录，新建或修改的 .md 文件在停止变化后自动发布 (模拟运行时只渲染)。
+// 已发布且未变化的文件按缓存跳过
+type Watcher struct {
+	root     string
+	debounce time.Duration
+	pub      Publisher
+	parser   *markdown.Parser
+	log      *logger.Logger
+
+	mutex   sync.Mutex
+	pending map[string]*time.Timer
+	ready   chan string
+	stop    <-chan struct{}
+}
+
+// New 创建文件监听器
+func New(root string, debounce time.Duration, pub Publisher, log *logger.Logger) *Watcher {
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	return &Watcher{
+		root:     root,
+		debounce: debounce,
+		pub:      pub,
+		parser:   markdown.NewParser(),
+		log:      log,
+		pending:  make(map[string]*time.Timer),
+		ready:    make(chan string, 64),
+	}
+}
+
+// Run 监听文件变化并发布，直到 ctx 取消
+func (w *Watcher) Run(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %w", err)
+	}
+	defer fsw.Close()
+	w.stop = ctx.Done()
+
+	// fsnotify 不支持递归监听，逐个添加子目录
+	if err := w.addTree(fsw, w.root); err != nil {
+		return err
+	}
+	w.log.Info("Watching for article changes", "path", w.root, "debounce", w.debounce)
+
+	// 发布在单独的 goroutine 中依次执行，避免阻塞事件读取
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case path := <-w.ready:
+				w.publish(ctx, path)
+			}
+		}
+	}()
+	defer func() {
+		w.stopTimers()
+		<-done
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			w.handle(fsw, event)
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			w.log.Warn("File watcher error", "error", err)
+		}
+	}
+}
+
+// addTree 监听目录及其全部子目录
+func (w *Watcher) addTree(fsw *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if err := fsw.Add(path); err != nil {
+			return fmt.Errorf("watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// handle 处理文件事件：新建的目录加入监听，.md 文件的写入或新建 (含编辑器的重命名保存) 重新计时
+func (w *Watcher) handle(fsw *fsnotify.Watcher, event fsnotify.Event) {
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+		return
+	}
+
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addTree(fsw, event.Name); err != nil {
+				w.log.Warn("Failed to watch new directory", "path", event.Name, "error", err)
+			}
+			return
+		}
+	}
+
+	if filepath.Ext(event.Name) != ".md" || strings.HasPrefix(filepath.Base(event.Name), ".") {
+		return
+	}
+	w.schedule(event.Name)
+}
+
+// schedule 文件停止变化 debounce 时间后加入发布队列
+func (w *Watcher) schedule(path string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if timer, ok := w.pending[path]; ok {
+		timer.Reset(w.debounce)
+		return
+	}
+	w.pending[path] = time.AfterFunc(w.debounce, func() {
+		w.mutex.Lock()
+		delete(w.pending, path)
+		w.mutex.Unlock()
+		select {
+		case w.ready <- path:
+		case <-w.stop:
+		}
+	})
+}
+
+// stopTimers 停止尚未触发的计时器
+func (w *Watcher) stopTimers() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for path, timer := range w.pending {
+		timer.Stop()
+		delete(w.pending, path)
+	}
+}
+
+// publish 发布变化的文件，已发布且未变化或设置了未来 publish_at 的文件跳过
+func (w *Watcher) publish(ctx context.Context, path string) {
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+
+	if article, err := w.parser.ParseFile(path); err == nil && article.PublishAt != "" {
+		if at, err := dates.ParseTimestamp(article.PublishAt, time.Local); err == nil && at.After(time.Now()) {
+			w.log.Info("Article is scheduled for later, skipping", "file", path, "publish_at", at.Format(time.RFC3339))
+			return
+		}
+	}
+
+	published, err := w.pub.IsPublished(path)
+	if err != nil {
+		w.log.Warn("Failed to check publish status", "file", path, "error", err)
+	}
+	if published {
+		w.log.Info("Article unchanged since last publish, skipping", "file", path)
+		return
+	}
+
+	w.log.Info("Article changed, publishing", "file", path)
+	result, err := w.pub.PublishArticle(ctx, path)
+	switch {
+	case err != nil:
+		w.log.Error("Failed to publish article", "file", path, "error", err)
+	case result.DryRun:
+		w.log.Info("Dry run: article rendered", "file", path, "output", result.OutputPath, "warnings", len(result.Warnings))
+	default:
+		w.log.Info("Article published", "file", path, "media_id", result.MediaID, "warnings", len(result.Warnings))
+	}
+}
@@ -1,24 +1,40 @@
 package wechat
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"auto-wx-post/internal/config"
+	"auto-wx-post/internal/metrics"
 )
 
-// Client 微信API客户端 (单例模式)
+// DefaultBaseURL 微信公众平台接口地址
+const DefaultBaseURL = "https://api.weixin.qq.com"
+
+// Client 微信API客户端 (每个公众号账号一个实例)
 type Client struct {
 	cfg         *config.WeChatConfig
+	baseURL     string
 	httpClient  *http.Client
 	token       *Token
 	tokenMutex  sync.RWMutex
 	retryConfig RetryConfig
+	timeouts    Timeouts
+	log         *slog.Logger
+	redactKeys  []string
+	tokenLoaded bool // 是否已尝试从 token_file 加载
 }
 
 // Token 访问令牌
@@ -27,6 +43,14 @@ type Token struct {
 	ExpiresAt   time.Time
 }
 
+// Timeouts 各类请求的超时时间，重试时每次请求单独计时，0 表示不限
+type Timeouts struct {
+	Token   time.Duration // 获取 access_token
+	Upload  time.Duration // 上传素材
+	Draft   time.Duration // 新建和更新草稿
+	Default time.Duration // 其他请求
+}
+
 // RetryConfig 重试配置
 type RetryConfig struct {
 	MaxRetries int
@@ -34,30 +58,104 @@ type RetryConfig struct {
 }
 
 var (
-	clientInstance *Client
-	clientOnce     sync.Once
+	defaultClient *Client
+	defaultMutex  sync.RWMutex
 )
 
-// NewClient 创建微信客户端 (单例)
-func NewClient(cfg *config.WeChatConfig, timeout time.Duration, maxRetries int) *Client {
-	clientOnce.Do(func() {
-		clientInstance = &Client{
-			cfg: cfg,
-			httpClient: &http.Client{
-				Timeout: timeout,
-			},
-			retryConfig: RetryConfig{
-				MaxRetries: maxRetries,
-				BaseDelay:  time.Second,
-			},
+// NewClient 创建微信客户端，每次调用返回新的实例
+// 超时由每次请求的 context 控制 (见 Timeouts)，http.Client 本身不设超时
+func NewClient(cfg *config.WeChatConfig, timeouts Timeouts, maxRetries int) *Client {
+	return &Client{
+		cfg:     cfg,
+		baseURL: DefaultBaseURL,
+		httpClient: &http.Client{
+			Transport: newTransport(cfg),
+		},
+		retryConfig: RetryConfig{
+			MaxRetries: maxRetries,
+			BaseDelay:  time.Second,
+		},
+		timeouts: timeouts,
+		log:      slog.Default(),
+	}
+}
+
+// withTimeout 为单次请求设置超时，timeout 为 0 时只返回可取消的 context
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// SetBaseURL 设置接口地址 (如测试时指向 httptest.Server)
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// SetDefaultClient 设置 GetClient 返回的客户端
+func SetDefaultClient(client *Client) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+	defaultClient = client
+}
+
+// SetLogger 设置请求日志记录器，redactKeys 为日志中需要额外脱敏的查询参数
+func (c *Client) SetLogger(log *slog.Logger, redactKeys []string) {
+	c.log = log
+	c.redactKeys = redactKeys
+}
+
+// defaultRedactKeys 始终脱敏的查询参数
+var defaultRedactKeys = []string{"access_token", "secret"}
+
+// redactURL 隐藏URL中的令牌和密钥，用于日志输出
+func (c *Client) redactURL(rawURL string) string {
+	u, err := neturl.Parse(rawURL)
+	if err != nil {
+		return "<invalid url>"
+	}
+
+	query := u.Query()
+	for _, key := range append(defaultRedactKeys, c.redactKeys...) {
+		if query.Has(key) {
+			query.Set(key, "REDACTED")
 		}
-	})
-	return clientInstance
+	}
+	u.RawQuery = query.Encode()
+
+	redacted := u.String()
+	if c.cfg.AppSecret != "" {
+		redacted = strings.ReplaceAll(redacted, c.cfg.AppSecret, "REDACTED")
+	}
+	return redacted
+}
+
+// redactError 脱敏传输错误 (*url.Error) 中的请求地址。该错误会写入日志、API 响应和 MCP 结果，
+// 不能带出令牌和密钥
+func (c *Client) redactError(err error) error {
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = c.redactURL(urlErr.URL)
+	}
+	return err
 }
 
-// GetClient 获取客户端实例
+// newTransport 按配置创建连接池。请求都发往同一主机，空闲连接数同时作为单主机上限
+func newTransport(cfg *config.WeChatConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = cfg.MaxIdleConns
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConns
+	transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	transport.IdleConnTimeout = time.Duration(cfg.IdleConnTimeout) * time.Second
+	return transport
+}
+
+// GetClient 获取通过 SetDefaultClient 设置的客户端
 func GetClient() *Client {
-	return clientInstance
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	return defaultClient
 }
 
 // GetAccessToken 获取访问令牌 (自动刷新)
@@ -79,13 +177,98 @@ func (c *Client) GetAccessToken(ctx context.Context) (string, error) {
 		return c.token.AccessToken, nil
 	}
 
+	// 首次使用时优先复用上次进程保存的令牌
+	if c.restoreToken() {
+		return c.token.AccessToken, nil
+	}
+
 	return c.refreshToken(ctx)
 }
 
-// refreshToken 刷新访问令牌
+// StartTokenRefresher 在后台提前刷新令牌，使请求无需承担刷新延迟，ctx 结束时退出
+func (c *Client) StartTokenRefresher(ctx context.Context, ahead time.Duration) {
+	go func() {
+		c.tokenMutex.Lock()
+		c.restoreToken()
+		c.tokenMutex.Unlock()
+
+		for {
+			c.tokenMutex.RLock()
+			var wait time.Duration
+			if c.token != nil {
+				wait = time.Until(c.token.ExpiresAt) - ahead
+			}
+			c.tokenMutex.RUnlock()
+
+			if wait > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(wait):
+				}
+			}
+
+			// 在锁外请求新令牌，当前令牌仍有效，刷新期间的请求不被阻塞
+			token, err := c.fetchToken(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				c.log.WarnContext(ctx, "background token refresh failed", "error", err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(30 * time.Second):
+				}
+				continue
+			}
+
+			c.tokenMutex.Lock()
+			c.token = token
+			c.tokenMutex.Unlock()
+			c.persistToken(ctx, token)
+		}
+	}()
+}
+
+// restoreToken 首次调用时从 token_file 恢复令牌，成功时返回 true (调用方需持有写锁)
+func (c *Client) restoreToken() bool {
+	if c.tokenLoaded {
+		return false
+	}
+	c.tokenLoaded = true
+
+	token := c.loadStoredToken()
+	if token == nil {
+		return false
+	}
+	c.token = token
+	return true
+}
+
+// refreshToken 刷新访问令牌 (调用方需持有写锁)
 func (c *Client) refreshToken(ctx context.Context) (string, error) {
+	token, err := c.fetchToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token = token
+	c.persistToken(ctx, token)
+	return token.AccessToken, nil
+}
+
+// persistToken 将令牌保存到 token_file，失败只记录警告
+func (c *Client) persistToken(ctx context.Context, token *Token) {
+	if err := c.saveStoredToken(token); err != nil {
+		c.log.WarnContext(ctx, "Failed to persist access token", "error", err)
+	}
+}
+
+// fetchToken 向微信请求新的访问令牌，不修改客户端状态，无需持有锁
+func (c *Client) fetchToken(ctx context.Context) (*Token, error) {
 	url := fmt.Sprintf(
-		"https://api.weixin.qq.com/cgi-bin/token?grant_type=client_credential&appid=%s&secret=%s",
+		"%s/cgi-bin/token?grant_type=client_credential&appid=%s&secret=%s",
+		c.baseURL,
 		c.cfg.AppID,
 		c.cfg.AppSecret,
 	)
@@ -97,32 +280,59 @@ func (c *Client) refreshToken(ctx context.Context) (string, error) {
 		ErrMsg      string `json:"errmsg"`
 	}
 
-	if err := c.doRequestWithRetry(ctx, "GET", url, nil, &response); err != nil {
-		return "", fmt.Errorf("fetch access token: %w", err)
+	if err := c.doRequestWithRetry(ctx, c.timeouts.Token, "GET", url, nil, &response); err != nil {
+		metrics.TokenRefreshesTotal.Inc("failure")
+		return nil, fmt.Errorf("fetch access token: %w", err)
 	}
 
-	if response.ErrCode != 0 {
-		return "", fmt.Errorf("wechat api error: %d - %s", response.ErrCode, response.ErrMsg)
+	if err := newAPIError("fetch access token", response.ErrCode, response.ErrMsg); err != nil {
+		metrics.TokenRefreshesTotal.Inc("failure")
+		return nil, err
 	}
+	metrics.TokenRefreshesTotal.Inc("success")
 
 	// 提前5分钟过期，避免边界情况
-	expiresAt := time.Now().Add(time.Duration(response.ExpiresIn-300) * time.Second)
-	c.token = &Token{
+	return &Token{
 		AccessToken: response.AccessToken,
-		ExpiresAt:   expiresAt,
-	}
+		ExpiresAt:   time.Now().Add(time.Duration(response.ExpiresIn-300) * time.Second),
+	}, nil
+}
+
+// maxRetryAfter 服务端 Retry-After 的上限，避免异常值使请求长时间挂起
+const maxRetryAfter = time.Minute
+
+// requestBody 为每次请求生成新的请求体，返回请求体 (nil 表示没有)、Content-Type 和长度。
+// ctx 为该次请求的 context，请求结束或超时时取消
+type requestBody func(ctx context.Context) (io.ReadCloser, string, int64, error)
 
-	return c.token.AccessToken, nil
+// doRequestWithRetry 执行HTTP请求并支持重试，body 为 JSON 请求体，timeout 为每次请求的超时时间
+func (c *Client) doRequestWithRetry(ctx context.Context, timeout time.Duration, method, url string, body []byte, result interface{}) error {
+	return c.doWithRetry(ctx, timeout, method, url, func(context.Context) (io.ReadCloser, string, int64, error) {
+		var contentType string
+		if method == "POST" {
+			contentType = "application/json; charset=utf-8"
+		}
+		if body == nil {
+			return nil, contentType, 0, nil
+		}
+		return io.NopCloser(bytes.NewReader(body)), contentType, int64(len(body)), nil
+	}, result)
 }
 
-// doRequestWithRetry 执行HTTP请求并支持重试
-func (c *Client) doRequestWithRetry(ctx context.Context, method, url string, body io.Reader, result interface{}) error {
+// doWithRetry 执行HTTP请求并支持重试，每次请求通过 newBody 重新生成请求体，timeout 为每次请求的超时时间。
+// 网络错误、5xx、429 和 wechat.retry_codes 中的 errcode 会重试，
+// 等待时间优先使用响应的 Retry-After，否则为带随机抖动的指数退避
+func (c *Client) doWithRetry(ctx context.Context, timeout time.Duration, method, url string, newBody requestBody, result interface{}) error {
 	var lastErr error
+	var retryAfter time.Duration
 
 	for i := 0; i <= c.retryConfig.MaxRetries; i++ {
 		if i > 0 {
-			// 指数退避
-			delay := c.retryConfig.BaseDelay * time.Duration(1<<uint(i-1))
+			delay := retryAfter
+			if delay <= 0 {
+				delay = c.backoff(i)
+			}
+			c.log.DebugContext(ctx, "retrying wechat request", "attempt", i+1, "delay", delay, "error", lastErr)
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
@@ -130,30 +340,61 @@ func (c *Client) doRequestWithRetry(ctx context.Context, method, url string, bod
 			}
 		}
 
-		req, err := http.NewRequestWithContext(ctx, method, url, body)
+		retryAfter = 0
+
+		// 每次重试重新生成请求体
+		attemptCtx, cancel := withTimeout(ctx, timeout)
+		body, contentType, size, err := newBody(attemptCtx)
 		if err != nil {
-			return fmt.Errorf("create request: %w", err)
+			cancel()
+			return err
+		}
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = body
 		}
 
-		if method == "POST" {
-			req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req, err := http.NewRequestWithContext(attemptCtx, method, url, reqBody)
+		if err != nil {
+			if body != nil {
+				body.Close()
+			}
+			cancel()
+			return fmt.Errorf("create request: %w", err)
+		}
+		if body != nil {
+			req.ContentLength = size
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
 		}
 
+		start := time.Now()
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
+			cancel()
+			err = c.redactError(err)
+			c.log.DebugContext(ctx, "wechat request failed",
+				"method", method, "url", c.redactURL(url), "attempt", i+1, "error", err)
 			lastErr = err
 			continue
 		}
+		c.log.DebugContext(ctx, "wechat request",
+			"method", method, "url", c.redactURL(url), "attempt", i+1,
+			"status", resp.StatusCode, "duration", time.Since(start))
 
-		defer resp.Body.Close()
+		// 立即关闭而不是 defer，重试前即释放连接
 		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		cancel()
 		if err != nil {
 			lastErr = err
 			continue
 		}
 
-		if resp.StatusCode >= 500 {
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
 			lastErr = fmt.Errorf("server error: %d", resp.StatusCode)
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
 			continue
 		}
 
@@ -161,6 +402,17 @@ func (c *Client) doRequestWithRetry(ctx context.Context, method, url string, bod
 			return fmt.Errorf("http error: %d - %s", resp.StatusCode, string(respBody))
 		}
 
+		// 限流等错误以 200 状态码返回，需检查响应中的 errcode
+		var status struct {
+			ErrCode int    `json:"errcode"`
+			ErrMsg  string `json:"errmsg"`
+		}
+		if json.Unmarshal(respBody, &status) == nil && status.ErrCode != 0 && containsCode(c.cfg.RetryCodes, status.ErrCode) {
+			lastErr = &APIError{Op: "wechat request", Code: status.ErrCode, Msg: status.ErrMsg}
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			continue
+		}
+
 		if result != nil {
 			if err := json.Unmarshal(respBody, result); err != nil {
 				return fmt.Errorf("parse response: %w", err)
@@ -173,13 +425,97 @@ func (c *Client) doRequestWithRetry(ctx context.Context, method, url string, bod
 	return fmt.Errorf("max retries exceeded: %w", lastErr)
 }
 
-// DoRequest 执行微信API请求 (自动附加token)
+// backoff 返回第 attempt 次重试前的等待时间：指数增长，并在 [d/2, 3d/2) 内随机抖动，
+// 避免多个客户端同时重试
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retryConfig.BaseDelay * time.Duration(1<<uint(attempt-1))
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay)))
+}
+
+// parseRetryAfter 解析 Retry-After 头 (秒数或 HTTP 日期)，无效或缺失时返回 0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if t, err := http.ParseTime(value); err == nil {
+		wait = t.Sub(now)
+	}
+
+	if wait < 0 {
+		return 0
+	}
+	if wait > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return wait
+}
+
+// DoRequest 执行微信API请求 (自动附加token)，使用默认超时。
+// 令牌失效 (如被其他进程刷新) 时强制刷新令牌并重试一次
 func (c *Client) DoRequest(ctx context.Context, method, endpoint string, body io.Reader, result interface{}) error {
-	token, err := c.GetAccessToken(ctx)
-	if err != nil {
-		return err
+	return c.doRequest(ctx, c.timeouts.Default, method, endpoint, body, result)
+}
+
+// doRequest 同 DoRequest，timeout 为每次请求的超时时间
+func (c *Client) doRequest(ctx context.Context, timeout time.Duration, method, endpoint string, body io.Reader, result interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return fmt.Errorf("read request body: %w", err)
+		}
 	}
 
-	url := fmt.Sprintf("%s?access_token=%s", endpoint, token)
-	return c.doRequestWithRetry(ctx, method, url, body, result)
+	for attempt := 0; ; attempt++ {
+		token, err := c.GetAccessToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		var raw json.RawMessage
+		url := fmt.Sprintf("%s?access_token=%s", endpoint, token)
+		if err := c.doRequestWithRetry(ctx, timeout, method, url, payload, &raw); err != nil {
+			return err
+		}
+
+		var status struct {
+			ErrCode int `json:"errcode"`
+		}
+		if attempt == 0 && json.Unmarshal(raw, &status) == nil && isInvalidTokenCode(status.ErrCode) {
+			c.log.WarnContext(ctx, "access token rejected, refreshing and retrying", "errcode", status.ErrCode)
+			c.invalidateToken(token)
+			continue
+		}
+
+		if result != nil {
+			if err := json.Unmarshal(raw, result); err != nil {
+				return fmt.Errorf("parse response: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+// invalidateToken 丢弃被微信拒绝的令牌。若 token_file 中已有其他进程刷新的新令牌则直接使用，
+// 否则下次 GetAccessToken 时重新获取
+func (c *Client) invalidateToken(stale string) {
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+
+	// 其他请求已经换过令牌
+	if c.token == nil || c.token.AccessToken != stale {
+		return
+	}
+
+	c.token = nil
+	if token := c.loadStoredToken(); token != nil && token.AccessToken != stale {
+		c.token = token
+	}
 }
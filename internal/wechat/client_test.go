@@ -0,0 +1,98 @@
+package wechat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"auto-wx-post/internal/config"
+)
+
+// closeTracker 记录每次请求的响应体是否已关闭
+type closeTracker struct {
+	next   http.RoundTripper
+	mutex  sync.Mutex
+	bodies []*trackedBody
+	open   []int // 发起第 i 次请求时仍未关闭的响应体数量
+}
+
+func (t *closeTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mutex.Lock()
+	open := 0
+	for _, b := range t.bodies {
+		if !b.closed {
+			open++
+		}
+	}
+	t.open = append(t.open, open)
+	t.mutex.Unlock()
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body := &trackedBody{ReadCloser: resp.Body, tracker: t}
+	t.mutex.Lock()
+	t.bodies = append(t.bodies, body)
+	t.mutex.Unlock()
+	resp.Body = body
+	return resp, nil
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	tracker *closeTracker
+	closed  bool
+}
+
+func (b *trackedBody) Close() error {
+	b.tracker.mutex.Lock()
+	b.closed = true
+	b.tracker.mutex.Unlock()
+	return b.ReadCloser.Close()
+}
+
+func TestRetryClosesEachResponseBody(t *testing.T) {
+	const failures = 3
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"errcode":-1,"errmsg":"system busy"}`)
+			return
+		}
+		fmt.Fprint(w, `{"errcode":0,"errmsg":"ok"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.WeChatConfig{}, Timeouts{}, failures)
+	client.retryConfig.BaseDelay = 0
+	tracker := &closeTracker{next: http.DefaultTransport}
+	client.httpClient.Transport = tracker
+
+	var result struct {
+		ErrCode int `json:"errcode"`
+	}
+	if err := client.doRequestWithRetry(context.Background(), 0, "GET", server.URL, nil, &result); err != nil {
+		t.Fatalf("doRequestWithRetry: %v", err)
+	}
+
+	if attempts != failures+1 {
+		t.Fatalf("attempts = %d, want %d", attempts, failures+1)
+	}
+	for i, open := range tracker.open {
+		if open != 0 {
+			t.Errorf("attempt %d started with %d response bodies still open", i+1, open)
+		}
+	}
+	for i, b := range tracker.bodies {
+		if !b.closed {
+			t.Errorf("response body of attempt %d not closed", i+1)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package wechat
+
+import (
+	"errors"
+	"fmt"
+)
+
+// APIError 微信接口返回的业务错误 (errcode 非 0)，可通过 errors.As 取出错误码
+type APIError struct {
+	Op   string // 出错的操作，如 "add draft"
+	Code int    // errcode
+	Msg  string // errmsg
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s error: %d - %s", e.Op, e.Code, e.Msg)
+}
+
+// newAPIError errcode 为 0 时返回 nil
+func newAPIError(op string, code int, msg string) error {
+	if code == 0 {
+		return nil
+	}
+	return &APIError{Op: op, Code: code, Msg: msg}
+}
+
+// ErrorCode 返回错误链中微信接口的 errcode，不是 APIError 时返回 0
+func ErrorCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
+
+// rateLimitCodes 表示限流或系统繁忙的错误码
+var rateLimitCodes = []int{45009, -1}
+
+// IsRateLimited 判断错误是否为微信限流或系统繁忙
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && containsCode(rateLimitCodes, apiErr.Code)
+}
+
+// invalidTokenCodes 表示令牌无效或过期的错误码
+var invalidTokenCodes = []int{40001, 40014, 42001}
+
+// isInvalidTokenCode 判断错误码是否表示令牌无效或过期
+func isInvalidTokenCode(code int) bool {
+	return containsCode(invalidTokenCodes, code)
+}
+
+// IsInvalidToken 判断错误是否为 access_token 无效或过期
+func IsInvalidToken(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && isInvalidTokenCode(apiErr.Code)
+}
+
+func containsCode(codes []int, code int) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
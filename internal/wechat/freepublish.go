@@ -0,0 +1,103 @@
+package wechat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// 发布状态 (freepublish/get 返回的 publish_status)
+const (
+	PublishStatusSuccess      = 0 // 发布成功
+	PublishStatusPublishing   = 1 // 发布中
+	PublishStatusOriginalFail = 2 // 原创审核不通过
+	PublishStatusFailed       = 3 // 常规失败
+	PublishStatusAuditFail    = 4 // 平台审核不通过
+	PublishStatusDeleted      = 5 // 成功后用户删除所有文章
+	PublishStatusBanned       = 6 // 成功后系统封禁所有文章
+)
+
+// FreePublishStatus 发布任务状态
+type FreePublishStatus struct {
+	PublishID     string `json:"publish_id"`
+	PublishStatus int    `json:"publish_status"`
+	ArticleID     string `json:"article_id"`
+	ArticleDetail struct {
+		Count int `json:"count"`
+		Item  []struct {
+			Idx        int    `json:"idx"`
+			ArticleURL string `json:"article_url"`
+		} `json:"item"`
+	} `json:"article_detail"`
+	FailIdx []int `json:"fail_idx"`
+}
+
+// Final 发布任务是否已结束 (不再处于发布中)
+func (s *FreePublishStatus) Final() bool {
+	return s.PublishStatus != PublishStatusPublishing
+}
+
+// Succeeded 发布是否成功
+func (s *FreePublishStatus) Succeeded() bool {
+	return s.PublishStatus == PublishStatusSuccess
+}
+
+// ArticleURL 返回第一篇文章的永久链接
+func (s *FreePublishStatus) ArticleURL() string {
+	if len(s.ArticleDetail.Item) == 0 {
+		return ""
+	}
+	return s.ArticleDetail.Item[0].ArticleURL
+}
+
+// SubmitFreePublish 将草稿提交发布，返回发布任务 publish_id。
+// 发布是异步的，需通过 GetFreePublishStatus 轮询结果
+func (c *Client) SubmitFreePublish(ctx context.Context, mediaID string) (string, error) {
+	data, err := json.Marshal(map[string]string{"media_id": mediaID})
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	endpoint := c.baseURL + "/cgi-bin/freepublish/submit"
+
+	var resp struct {
+		ErrCode   int    `json:"errcode"`
+		ErrMsg    string `json:"errmsg"`
+		PublishID string `json:"publish_id"`
+	}
+	if err := c.DoRequest(ctx, "POST", endpoint, bytes.NewReader(data), &resp); err != nil {
+		return "", err
+	}
+
+	if err := newAPIError("submit publish", resp.ErrCode, resp.ErrMsg); err != nil {
+		return "", err
+	}
+
+	return resp.PublishID, nil
+}
+
+// GetFreePublishStatus 查询发布任务状态
+func (c *Client) GetFreePublishStatus(ctx context.Context, publishID string) (*FreePublishStatus, error) {
+	data, err := json.Marshal(map[string]string{"publish_id": publishID})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	endpoint := c.baseURL + "/cgi-bin/freepublish/get"
+
+	var resp struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		FreePublishStatus
+	}
+	if err := c.DoRequest(ctx, "POST", endpoint, bytes.NewReader(data), &resp); err != nil {
+		return nil, err
+	}
+
+	if err := newAPIError("get publish status", resp.ErrCode, resp.ErrMsg); err != nil {
+		return nil, err
+	}
+
+	return &resp.FreePublishStatus, nil
+}
@@ -50,62 +50,115 @@ type DraftResponse struct {
 	ErrMsg  string `json:"errmsg"`
 }
 
-// UploadPermanentMedia 上传永久素材
+// UploadPermanentMedia 上传永久素材。文件边读边上传，不整体读入内存；ctx 取消时中止上传
 func (c *Client) UploadPermanentMedia(ctx context.Context, mediaType MediaType, filePath string) (*MediaUploadResult, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("open file: %w", err)
-	}
-	defer file.Close()
+	for attempt := 0; ; attempt++ {
+		token, err := c.GetAccessToken(ctx)
+		if err != nil {
+			return nil, err
+		}
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+		url := fmt.Sprintf(
+			"%s/cgi-bin/material/add_material?access_token=%s&type=%s",
+			c.baseURL, token, mediaType,
+		)
 
-	part, err := writer.CreateFormFile("media", filepath.Base(filePath))
-	if err != nil {
-		return nil, fmt.Errorf("create form file: %w", err)
-	}
+		result, err := c.uploadMedia(ctx, url, filePath)
+		if err != nil {
+			return nil, err
+		}
 
-	if _, err := io.Copy(part, file); err != nil {
-		return nil, fmt.Errorf("copy file: %w", err)
-	}
+		// 令牌失效时刷新并重试一次
+		if attempt == 0 && isInvalidTokenCode(result.ErrCode) {
+			c.log.WarnContext(ctx, "access token rejected, refreshing and retrying", "errcode", result.ErrCode)
+			c.invalidateToken(token)
+			continue
+		}
 
-	contentType := writer.FormDataContentType()
-	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("close writer: %w", err)
-	}
+		if err := newAPIError("upload media", result.ErrCode, result.ErrMsg); err != nil {
+			return nil, err
+		}
 
-	token, err := c.GetAccessToken(ctx)
-	if err != nil {
-		return nil, err
+		return &result.MediaUploadResult, nil
 	}
+}
 
-	url := fmt.Sprintf(
-		"https://api.weixin.qq.com/cgi-bin/material/add_material?access_token=%s&type=%s",
-		token, mediaType,
-	)
+// mediaUploadResponse 上传素材接口的响应
+type mediaUploadResponse struct {
+	MediaUploadResult
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
 
-	req, err := c.httpClient.Post(url, contentType, body)
+// uploadMedia 以 multipart 表单上传文件，与其他请求使用相同的重试和退避策略，
+// 每次重试重新打开文件生成请求体
+func (c *Client) uploadMedia(ctx context.Context, url, filePath string) (*mediaUploadResponse, error) {
+	var result mediaUploadResponse
+	err := c.doWithRetry(ctx, c.timeouts.Upload, "POST", url, func(ctx context.Context) (io.ReadCloser, string, int64, error) {
+		return multipartFile(ctx, "media", filePath)
+	}, &result)
 	if err != nil {
 		return nil, fmt.Errorf("upload media: %w", err)
 	}
-	defer req.Body.Close()
+	return &result, nil
+}
 
-	var result struct {
-		MediaUploadResult
-		ErrCode int    `json:"errcode"`
-		ErrMsg  string `json:"errmsg"`
+// multipartFile 通过 io.Pipe 边读文件边生成只含一个文件字段的 multipart 请求体，返回请求体、
+// Content-Type 和总长度 (预先计算，请求带 Content-Length 而不是分块传输)。
+// ctx 取消或请求体被关闭时，后台复制随之结束
+func multipartFile(ctx context.Context, field, filePath string) (io.ReadCloser, string, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("open file: %w", err)
 	}
-
-	if err := json.NewDecoder(req.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, "", 0, fmt.Errorf("stat file: %w", err)
 	}
 
-	if result.ErrCode != 0 {
-		return nil, fmt.Errorf("wechat error: %d - %s", result.ErrCode, result.ErrMsg)
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	fileName := filepath.Base(filePath)
+
+	// 表单头尾的长度只取决于分隔符和文件名，用同样的分隔符写一份空表单即可得到
+	var envelope bytes.Buffer
+	sizer := multipart.NewWriter(&envelope)
+	sizer.SetBoundary(writer.Boundary())
+	if _, err := sizer.CreateFormFile(field, fileName); err != nil {
+		file.Close()
+		return nil, "", 0, fmt.Errorf("create form file: %w", err)
 	}
+	sizer.Close()
+
+	go func() {
+		defer file.Close()
 
-	return &result.MediaUploadResult, nil
+		part, err := writer.CreateFormFile(field, fileName)
+		if err == nil {
+			_, err = io.Copy(part, &contextReader{ctx: ctx, r: file})
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		// err 为 nil 时读取端收到 EOF
+		pw.CloseWithError(err)
+	}()
+
+	return pr, writer.FormDataContentType(), int64(envelope.Len()) + info.Size(), nil
+}
+
+// contextReader 每次读取前检查 ctx，使长时间的复制可被取消
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
 }
 
 // AddDraft 添加草稿
@@ -116,16 +169,100 @@ func (c *Client) AddDraft(ctx context.Context, articles []Article) (string, erro
 		return "", fmt.Errorf("marshal articles: %w", err)
 	}
 
-	endpoint := "https://api.weixin.qq.com/cgi-bin/draft/add"
+	endpoint := c.baseURL + "/cgi-bin/draft/add"
 
 	var resp DraftResponse
-	if err := c.DoRequest(ctx, "POST", endpoint, bytes.NewReader(data), &resp); err != nil {
+	if err := c.doRequest(ctx, c.timeouts.Draft, "POST", endpoint, bytes.NewReader(data), &resp); err != nil {
 		return "", err
 	}
 
-	if resp.ErrCode != 0 {
-		return "", fmt.Errorf("add draft error: %d - %s", resp.ErrCode, resp.ErrMsg)
+	if err := newAPIError("add draft", resp.ErrCode, resp.ErrMsg); err != nil {
+		return "", err
 	}
 
 	return resp.MediaID, nil
 }
+
+// UpdateDraft 更新草稿中指定位置的文章
+func (c *Client) UpdateDraft(ctx context.Context, mediaID string, index int, article Article) error {
+	reqBody := map[string]interface{}{
+		"media_id": mediaID,
+		"index":    index,
+		"articles": article,
+	}
+	data, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("marshal article: %w", err)
+	}
+
+	endpoint := c.baseURL + "/cgi-bin/draft/update"
+
+	var resp DraftResponse
+	if err := c.doRequest(ctx, c.timeouts.Draft, "POST", endpoint, bytes.NewReader(data), &resp); err != nil {
+		return err
+	}
+
+	return newAPIError("update draft", resp.ErrCode, resp.ErrMsg)
+}
+
+// DeleteDraft 删除草稿
+func (c *Client) DeleteDraft(ctx context.Context, mediaID string) error {
+	return c.postMediaID(ctx, c.baseURL+"/cgi-bin/draft/delete", mediaID, "delete draft")
+}
+
+// DeleteMaterial 删除永久素材
+func (c *Client) DeleteMaterial(ctx context.Context, mediaID string) error {
+	return c.postMediaID(ctx, c.baseURL+"/cgi-bin/material/del_material", mediaID, "delete material")
+}
+
+// postMediaID 调用仅需 media_id 参数的接口
+func (c *Client) postMediaID(ctx context.Context, endpoint, mediaID, action string) error {
+	data, err := json.Marshal(map[string]string{"media_id": mediaID})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	var resp struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := c.DoRequest(ctx, "POST", endpoint, bytes.NewReader(data), &resp); err != nil {
+		return err
+	}
+
+	return newAPIError(action, resp.ErrCode, resp.ErrMsg)
+}
+
+// PreviewToUser 将图文消息预览发送给指定用户 (需已关注公众号)
+func (c *Client) PreviewToUser(ctx context.Context, openID, mediaID string) error {
+	reqBody := map[string]interface{}{
+		"touser":  openID,
+		"msgtype": "mpnews",
+		"mpnews": map[string]string{
+			"media_id": mediaID,
+		},
+	}
+	data, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("marshal preview request: %w", err)
+	}
+
+	endpoint := c.baseURL + "/cgi-bin/message/mass/preview"
+
+	var resp struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := c.DoRequest(ctx, "POST", endpoint, bytes.NewReader(data), &resp); err != nil {
+		return err
+	}
+
+	switch resp.ErrCode {
+	case 40003:
+		return &APIError{Op: "preview", Code: resp.ErrCode, Msg: "invalid openid " + openID}
+	case 43004:
+		return &APIError{Op: "preview", Code: resp.ErrCode, Msg: fmt.Sprintf("user %s is not a follower", openID)}
+	default:
+		return newAPIError("preview", resp.ErrCode, resp.ErrMsg)
+	}
+}
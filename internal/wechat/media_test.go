@@ -0,0 +1,68 @@
+package wechat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"auto-wx-post/internal/config"
+)
+
+func TestUploadRetriesWithFreshBody(t *testing.T) {
+	content := []byte("\x89PNG fake image data")
+	filePath := filepath.Join(t.TempDir(), "a.png")
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		t.Fatalf("write image: %v", err)
+	}
+
+	var uploads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cgi-bin/token" {
+			fmt.Fprint(w, `{"access_token":"token","expires_in":7200}`)
+			return
+		}
+
+		uploads++
+		file, _, err := r.FormFile("media")
+		if err != nil {
+			t.Errorf("upload %d: read form file: %v", uploads, err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		got, _ := io.ReadAll(file)
+		if string(got) != string(content) {
+			t.Errorf("upload %d: file = %q, want %q", uploads, got, content)
+		}
+
+		switch uploads {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			fmt.Fprint(w, `{"errcode":45009,"errmsg":"reach max api daily quota limit"}`)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			fmt.Fprint(w, `{"media_id":"media1","url":"http://mmbiz.qpic.cn/1"}`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.WeChatConfig{RetryCodes: []int{45009}}, Timeouts{}, 3)
+	client.SetBaseURL(server.URL)
+	client.retryConfig.BaseDelay = 0
+
+	result, err := client.UploadPermanentMedia(context.Background(), MediaTypeImage, filePath)
+	if err != nil {
+		t.Fatalf("UploadPermanentMedia: %v", err)
+	}
+	if result.MediaID != "media1" {
+		t.Errorf("media_id = %q, want media1", result.MediaID)
+	}
+	if uploads != 3 {
+		t.Errorf("uploads = %d, want 3", uploads)
+	}
+}
@@ -0,0 +1,72 @@
+package wechat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// storedToken 持久化到磁盘的令牌
+type storedToken struct {
+	AppID       string    `json:"app_id"`
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// loadStoredToken 从 wechat.token_file 读取令牌，文件不存在、损坏、属于其他 AppID 或已过期时返回 nil
+func (c *Client) loadStoredToken() *Token {
+	path := c.cfg.TokenFile
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.log.Warn("Failed to read token file", "path", path, "error", err)
+		}
+		return nil
+	}
+
+	var stored storedToken
+	if err := json.Unmarshal(data, &stored); err != nil {
+		c.log.Warn("Ignoring corrupt token file", "path", path, "error", err)
+		return nil
+	}
+	if stored.AppID != c.cfg.AppID || stored.AccessToken == "" || !time.Now().Before(stored.ExpiresAt) {
+		return nil
+	}
+
+	return &Token{AccessToken: stored.AccessToken, ExpiresAt: stored.ExpiresAt}
+}
+
+// saveStoredToken 将令牌写入 wechat.token_file (先写临时文件再重命名，避免并发进程读到半个文件)
+func (c *Client) saveStoredToken(token *Token) error {
+	path := c.cfg.TokenFile
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(storedToken{
+		AppID:       c.cfg.AppID,
+		AccessToken: token.AccessToken,
+		ExpiresAt:   token.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal token: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create token dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write token file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename token file: %w", err)
+	}
+	return nil
+}
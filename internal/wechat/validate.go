@@ -0,0 +1,68 @@
+package wechat
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// 草稿接口的字段限制
+const (
+	MaxTitleLength     = 64      // 标题最多 64 字
+	MaxAuthorLength    = 16      // 作者最多 16 字
+	MaxDigestLength    = 120     // 摘要最多 120 字
+	MaxContentLength   = 20000   // 正文少于 2 万字符
+	MaxContentBytes    = 1 << 20 // 正文小于 1M
+	MaxSourceURLLength = 1024    // 原文地址最长 1KB
+	MaxGroupArticles   = 8       // 一篇多图文草稿最多 8 篇文章
+)
+
+// ValidateArticle 在本地按已知的微信限制校验草稿数据，返回发现的所有问题
+func ValidateArticle(a Article) []error {
+	var errs []error
+
+	if strings.TrimSpace(a.Title) == "" {
+		errs = append(errs, fmt.Errorf("title is required"))
+	} else if n := utf8.RuneCountInString(a.Title); n > MaxTitleLength {
+		errs = append(errs, fmt.Errorf("title is %d characters, max %d", n, MaxTitleLength))
+	}
+
+	if a.ThumbMediaID == "" {
+		errs = append(errs, fmt.Errorf("thumb_media_id is required"))
+	}
+
+	if n := utf8.RuneCountInString(a.Author); n > MaxAuthorLength {
+		errs = append(errs, fmt.Errorf("author is %d characters, max %d", n, MaxAuthorLength))
+	}
+
+	if n := utf8.RuneCountInString(a.Digest); n > MaxDigestLength {
+		errs = append(errs, fmt.Errorf("digest is %d characters, max %d", n, MaxDigestLength))
+	}
+
+	if a.ShowCoverPic != 0 && a.ShowCoverPic != 1 {
+		errs = append(errs, fmt.Errorf("show_cover_pic must be 0 or 1, got %d", a.ShowCoverPic))
+	}
+
+	if strings.TrimSpace(a.Content) == "" {
+		errs = append(errs, fmt.Errorf("content is required"))
+	} else {
+		if n := utf8.RuneCountInString(a.Content); n >= MaxContentLength {
+			errs = append(errs, fmt.Errorf("content is %d characters, must be under %d", n, MaxContentLength))
+		}
+		if n := len(a.Content); n >= MaxContentBytes {
+			errs = append(errs, fmt.Errorf("content is %d bytes, must be under %d", n, MaxContentBytes))
+		}
+	}
+
+	if a.ContentSourceURL != "" {
+		if len(a.ContentSourceURL) > MaxSourceURLLength {
+			errs = append(errs, fmt.Errorf("content_source_url is %d bytes, max %d", len(a.ContentSourceURL), MaxSourceURLLength))
+		}
+		if u, err := url.Parse(a.ContentSourceURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, fmt.Errorf("content_source_url is not a valid http(s) URL: %q", a.ContentSourceURL))
+		}
+	}
+
+	return errs
+}
@@ -2,32 +2,58 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
-	"net/http"
+	"log/slog"
+	"net"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"auto-wx-post/internal/api"
 	"auto-wx-post/internal/cache"
+	"auto-wx-post/internal/catalog"
 	"auto-wx-post/internal/config"
+	"auto-wx-post/internal/dates"
 	"auto-wx-post/internal/logger"
+	"auto-wx-post/internal/markdown"
 	"auto-wx-post/internal/mcp"
 	"auto-wx-post/internal/media"
 	"auto-wx-post/internal/publisher"
+	"auto-wx-post/internal/scheduler"
+	"auto-wx-post/internal/stats"
+	"auto-wx-post/internal/watcher"
 	"auto-wx-post/internal/wechat"
 )
 
 var (
 	configPath = flag.String("config", "config.yaml", "配置文件路径")
 	clearCache = flag.Bool("clear-cache", false, "清空缓存")
-	dryRun     = flag.Bool("dry-run", false, "模拟运行(不实际发布)")
+	dryRun     = flag.Bool("dry-run", false, "模拟运行(不调用微信接口，渲染并校验草稿数据)")
+	dryRunDir  = flag.String("dry-run-dir", "dry-run", "模拟运行时写出最终HTML和元数据的目录 (留空不写出)")
 	mcpServer  = flag.Bool("mcp", false, "启动 MCP (Model Context Protocol) 服务器")
+	mcpAddr    = flag.String("mcp-addr", "", "MCP 服务器通过 HTTP 监听的地址 (如 :8081，留空则使用 stdio)")
 	httpServer = flag.Bool("http", false, "启动 HTTP API 服务器")
-	httpPort   = flag.String("port", "8080", "HTTP 服务器端口")
-	apiKey     = flag.String("api-key", "", "API 认证密钥 (留空则不启用认证)")
+	httpPort   = flag.String("port", "8080", "HTTP 服务器端口或监听地址 (如 8080 或 127.0.0.1:8080)")
+	apiKey     = flag.String("api-key", "", "API 认证密钥 (优先于 AWP_API_KEY 环境变量和 api.api_key)")
+	skipCheck  = flag.Bool("skip-image-check", false, "跳过发布前的图片预检查")
+	showStats  = flag.Bool("stats", false, "输出文章仓库统计信息")
+	jsonOutput = flag.Bool("json", false, "以 JSON 格式输出 (用于 -stats)")
+	since      = flag.String("since", "", "扫描开始日期 (YYYY-MM-DD 或 today、-7d 等相对表达式)")
+	until      = flag.String("until", "", "扫描结束日期 (YYYY-MM-DD 或 today、+3d 等相对表达式)")
+	previewTo  = flag.String("preview-to", "", "发布草稿后预览发送给该测试用户 openid")
+	restyle    = flag.Bool("restyle", false, "使用当前模板重新排版已发布且内容未变化的草稿")
+	smokeTest  = flag.Bool("smoke-test", false, "创建测试草稿后立即删除，验证微信接口可用 (输出 JSON)")
+	account    = flag.String("account", "", "发布到的公众号账号 (wechat.accounts 中的名称，默认 wechat.default_account)")
+	schedule   = flag.Bool("schedule", false, "以守护进程运行，按文章 front matter 中的 publish_at 定时发布")
+	watch      = flag.Bool("watch", false, "监听文章目录，新建或修改的文章保存后自动发布 (可配合 -dry-run 只渲染)")
+	file       = flag.String("file", "", "直接发布指定的文章文件，不按日期扫描")
+	force      = flag.Bool("force", false, "重新发布已发布过的文章 (配合 -dry-run 时重新渲染)")
 )
 
 func main() {
@@ -40,18 +66,25 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *skipCheck {
+		cfg.Publish.SkipImageCheck = true
+	}
+
 	// 初始化日志
 	log, err := logger.NewLogger(&cfg.Log)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "初始化日志失败: %v\n", err)
 		os.Exit(1)
 	}
+	// 未持有日志记录器的组件 (如图片上传) 通过 slog 默认记录器输出，同样带上 request_id
+	slog.SetDefault(log.Logger)
 
 	log.Info("启动微信公众号自动发布工具")
 	startTime := time.Now()
 
 	// 初始化缓存
-	cacheManager, err := cache.NewManager(cfg.Cache.StoreFile)
+	accountName := cfg.ResolveAccount(*account)
+	cacheManager, err := openCache(cfg, accountName)
 	if err != nil {
 		log.Error("初始化缓存失败", "error", err)
 		os.Exit(1)
@@ -68,33 +101,116 @@ func main() {
 
 	log.Info("缓存加载完成", "size", cacheManager.Size())
 
-	// 初始化微信客户端
-	timeout := time.Duration(cfg.Publish.Timeout) * time.Second
-	wechatClient := wechat.NewClient(&cfg.WeChat, timeout, cfg.Publish.MaxRetries)
-
-	// 初始化媒体管理器
-	mediaManager, err := media.NewManager(wechatClient, cacheManager, &cfg.Image)
-	if err != nil {
-		log.Error("初始化媒体管理器失败", "error", err)
-		os.Exit(1)
+	if *showStats {
+		summary, err := stats.Collect(catalog.NewFinder(cfg.Blog.SourcePath, markdown.NewParser(), cacheManager, log.Logger))
+		if err != nil {
+			log.Error("统计文章失败", "error", err)
+			os.Exit(1)
+		}
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(summary, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Print(summary.Text())
+		}
+		return
 	}
+
+	// 初始化所选账号的微信客户端、媒体管理器和发布器
+	pipelines := make(map[string]*accountPipeline)
 	defer func() {
-		if err := mediaManager.Cleanup(); err != nil {
-			log.Warn("清理临时文件失败", "error", err)
+		for _, p := range pipelines {
+			if err := p.media.Cleanup(); err != nil {
+				log.Warn("清理临时文件失败", "error", err)
+			}
 		}
 	}()
 
-	// 初始化发布器
-	pub, err := publisher.NewPublisher(cfg, wechatClient, cacheManager, mediaManager, log)
+	selected, err := newAccountPipeline(cfg, accountName, cacheManager, log)
 	if err != nil {
-		log.Error("初始化发布器失败", "error", err)
+		log.Error("初始化发布器失败", "account", accountName, "error", err)
 		os.Exit(1)
 	}
+	pipelines[accountName] = selected
+	wechat.SetDefaultClient(selected.client)
+	log.Info("使用公众号账号", "account", accountName)
+
+	router := &accountRouter{
+		baseCfg:   cfg,
+		account:   accountName,
+		pipelines: pipelines,
+		log:       log,
+	}
+	cfg = selected.cfg
+	wechatClient := selected.client
+	mediaManager := selected.media
+	pub := selected.pub
+
+	// 冒烟测试，失败时以非零状态退出，便于定时任务告警
+	if *smokeTest {
+		result := pub.SmokeTest(context.Background())
+		data, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(data))
+		if !result.OK {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 重新排版已发布的草稿
+	if *restyle {
+		restyled, skipped, failed := 0, 0, 0
+		for _, r := range pub.Restyle(context.Background()) {
+			switch {
+			case r.Error != "":
+				failed++
+			case r.Restyled:
+				log.Info("草稿已重新排版", "file", r.FilePath, "media_id", r.MediaID)
+				restyled++
+			default:
+				skipped++
+			}
+		}
+		log.Info("重新排版完成", "restyled", restyled, "skipped", skipped, "error", failed)
+		return
+	}
+
+	// 服务器模式下可在后台提前刷新 token
+	if (*mcpServer || *httpServer) && cfg.WeChat.BackgroundRefresh {
+		refreshCtx, cancelRefresh := context.WithCancel(context.Background())
+		defer cancelRefresh()
+		wechatClient.StartTokenRefresher(refreshCtx, 2*time.Minute)
+		log.Info("已启用后台 token 刷新")
+	}
 
 	// MCP 服务器模式
 	if *mcpServer {
 		log.Info("启动 MCP 服务器模式")
 		mcpSrv := mcp.NewServer(cfg, wechatClient, cacheManager, mediaManager, pub, log)
+		mcpSrv.SetPublisherResolver(router.publisherFor)
+
+		if *mcpAddr != "" {
+			keys := []string{resolveAPIKey(*apiKey, cfg)}
+			for _, k := range cfg.API.Keys {
+				keys = append(keys, k.Key)
+			}
+			transport := mcp.NewHTTPTransport(mcpSrv, keys)
+
+			if err := checkAuthConfigured(*mcpAddr, false, transport.AuthEnabled()); err != nil {
+				log.Error("MCP 服务器无法启动", "error", err)
+				os.Exit(1)
+			}
+			log.Info("MCP 服务器通过 HTTP 启动", "address", *mcpAddr, "endpoint", "/mcp")
+			if !transport.AuthEnabled() {
+				log.Warn("MCP HTTP 认证未启用，仅监听本机地址")
+			}
+			if err := transport.ListenAndServe(*mcpAddr); err != nil {
+				log.Error("MCP 服务器错误", "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		handler := mcp.NewHandler(mcpSrv)
 
 		ctx := context.Background()
@@ -109,31 +225,123 @@ func main() {
 	if *httpServer {
 		log.Info("启动 HTTP API 服务器", "port", *httpPort)
 
-		apiSrv := api.NewServer(cfg, wechatClient, cacheManager, mediaManager, pub, log, *apiKey)
-		handler := apiSrv.SetupRoutes()
+		apiSrv := api.NewServer(cfg, wechatClient, cacheManager, mediaManager, pub, log, resolveAPIKey(*apiKey, cfg))
+		apiSrv.SetPublisherResolver(router.publisherFor)
 
-		addr := ":" + *httpPort
-		log.Info("HTTP API 服务器启动", "address", addr)
-		if *apiKey != "" {
+		addr := *httpPort
+		if !strings.Contains(addr, ":") {
+			addr = ":" + addr
+		}
+		tls := cfg.API.TLSCert != "" || cfg.API.TLSKey != ""
+		if err := checkAuthConfigured(addr, tls, apiSrv.AuthEnabled()); err != nil {
+			log.Error("HTTP 服务器无法启动", "error", err)
+			os.Exit(1)
+		}
+		log.Info("HTTP API 服务器启动", "address", addr, "tls", tls)
+		if apiSrv.AuthEnabled() {
 			log.Info("API 认证已启用")
 		} else {
-			log.Warn("API 认证未启用，建议使用 -api-key 参数设置密钥")
+			log.Warn("API 认证未启用，仅监听本机地址")
 		}
 
-		if err := http.ListenAndServe(addr, handler); err != nil {
+		if err := apiSrv.StartTLS(addr); err != nil {
 			log.Error("HTTP 服务器错误", "error", err)
 			os.Exit(1)
 		}
 		return
 	}
 
+	// 定时发布模式，收到中断信号后退出
+	if *schedule {
+		queue, err := scheduler.NewQueue(cfg.Schedule.QueueFile)
+		if err != nil {
+			log.Error("打开定时发布队列失败", "error", err)
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		log.Info("启动定时发布", "queue", cfg.Schedule.QueueFile, "poll_interval", cfg.Schedule.PollInterval)
+		if err := scheduler.New(cfg, queue, router, log).Run(ctx); err != nil {
+			log.Error("定时发布错误", "error", err)
+			os.Exit(1)
+		}
+		log.Info("定时发布已停止")
+		return
+	}
+
+	// 文件监听模式，收到中断信号后退出
+	if *watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		log.Info("启动文件监听模式", "path", cfg.Blog.SourcePath, "dry_run", *dryRun)
+		if err := watcher.New(cfg.Blog.SourcePath, watcher.DefaultDebounce, router, log).Run(ctx); err != nil {
+			log.Error("文件监听错误", "error", err)
+			os.Exit(1)
+		}
+		log.Info("文件监听已停止")
+		return
+	}
+
 	// 扫描并发布文章
 	ctx := context.Background()
 
-	// 计算日期范围
+	// 发布单篇文章，不按日期扫描
+	if *file != "" {
+		p, err := router.pipelineFor(*file)
+		if err != nil {
+			log.Error("初始化账号失败", "file", *file, "error", err)
+			os.Exit(1)
+		}
+
+		publish := p.pub.PublishArticle
+		if processed, _ := p.pub.IsPublished(*file); processed {
+			if !*force {
+				log.Info("文章已发布，跳过 (使用 -force 重新发布)", "file", *file)
+				return
+			}
+			log.Info("文章已发布，强制重新发布", "file", *file)
+			publish = p.pub.RepublishArticle
+		}
+
+		result, err := publish(ctx, *file)
+		if err != nil {
+			log.Error("发布文章失败", "file", *file, "error", err)
+			os.Exit(1)
+		}
+		if result.DryRun {
+			log.Info("模拟运行：草稿数据校验通过", "file", *file, "output", result.OutputPath, "warnings", len(result.Warnings))
+			return
+		}
+		log.Info("文章发布成功", "file", *file, "media_id", result.MediaID, "images", result.ImagesUploaded)
+
+		if *previewTo != "" && result.MediaID != "" {
+			if err := p.pub.PreviewToUser(ctx, result.MediaID, *previewTo); err != nil {
+				log.Error("发送预览失败", "file", *file, "error", err)
+			}
+		}
+		return
+	}
+
+	// 计算日期范围，与 -since/-until 一样从当天零点起算，保证按天遍历时包含结束日
 	now := time.Now()
-	startDate := now.AddDate(0, 0, -cfg.Publish.DaysBefore)
-	endDate := now.AddDate(0, 0, cfg.Publish.DaysAfter)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	startDate := today.AddDate(0, 0, -cfg.Publish.DaysBefore)
+	endDate := today.AddDate(0, 0, cfg.Publish.DaysAfter)
+	if *since != "" {
+		if startDate, err = dates.Resolve(*since, now); err != nil {
+			log.Error("解析 -since 失败", "error", err)
+			os.Exit(1)
+		}
+	}
+	if *until != "" {
+		if endDate, err = dates.Resolve(*until, now); err != nil {
+			log.Error("解析 -until 失败", "error", err)
+			os.Exit(1)
+		}
+	}
 
 	log.Info("开始扫描文章",
 		"start_date", startDate.Format("2006-01-02"),
@@ -143,17 +351,21 @@ func main() {
 	successCount := 0
 	errorCount := 0
 	skipCount := 0
+	pacer := publisher.NewPacer(
+		time.Duration(cfg.Publish.Interval)*time.Second,
+		time.Duration(cfg.Publish.RateLimitBackoff)*time.Second)
+
+	// 一次扫描全部文章，按 front matter 中的日期分组
+	articlesByDate, err := findArticlesByDate(cfg.Blog.SourcePath, startDate, endDate)
+	if err != nil {
+		log.Error("查找文章失败", "error", err)
+		os.Exit(1)
+	}
 
 	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
 		dateStr := d.Format("2006-01-02")
 
-		// 查找匹配日期的文章
-		articles, err := findArticlesByDate(cfg.Blog.SourcePath, dateStr)
-		if err != nil {
-			log.Error("查找文章失败", "date", dateStr, "error", err)
-			continue
-		}
-
+		articles := articlesByDate[dateStr]
 		if len(articles) == 0 {
 			continue
 		}
@@ -162,28 +374,61 @@ func main() {
 
 		// 发布文章
 		for _, article := range articles {
-			// 检查是否已处理
-			processed, _ := cacheManager.IsFileProcessed(article)
-			if processed {
-				log.Info("文章已发布，跳过", "file", article)
+			// 设置了 publish_at 的文章由 -schedule 模式按时发布
+			if at := articlePublishAt(article); at.After(time.Now()) {
+				log.Info("文章设置了定时发布，跳过", "file", article, "publish_at", at.Format(time.RFC3339))
 				skipCount++
 				continue
 			}
 
-			if *dryRun {
-				log.Info("模拟运行模式，跳过实际发布", "file", article)
+			// front matter 可指定发布到其他账号
+			p, err := router.pipelineFor(article)
+			if err != nil {
+				log.Error("初始化账号失败", "file", article, "error", err)
+				errorCount++
 				continue
 			}
+			articlePub := p.pub
+
+			// 检查是否已处理，-force 时重新发布
+			processed, _ := articlePub.IsPublished(article)
+			if processed && !*force {
+				log.Info("文章已发布，跳过", "file", article)
+				skipCount++
+				continue
+			}
+			publish := articlePub.PublishArticle
+			if processed {
+				log.Info("文章已发布，强制重新发布", "file", article)
+				publish = articlePub.RepublishArticle
+			}
 
-			if err := pub.PublishArticle(ctx, article); err != nil {
+			result, err := publish(ctx, article)
+			if err != nil {
 				log.Error("发布文章失败", "file", article, "error", err)
 				errorCount++
+			} else if result.DryRun {
+				log.Info("模拟运行：草稿数据校验通过", "file", article, "output", result.OutputPath, "warnings", len(result.Warnings))
+				successCount++
+				continue
 			} else {
+				log.Info("文章发布成功",
+					"file", article,
+					"media_id", result.MediaID,
+					"images", result.ImagesUploaded)
 				successCount++
+
+				if *previewTo != "" && result.MediaID != "" {
+					if err := articlePub.PreviewToUser(ctx, result.MediaID, *previewTo); err != nil {
+						log.Error("发送预览失败", "file", article, "error", err)
+					}
+				}
 			}
 
-			// 避免频繁请求
-			time.Sleep(2 * time.Second)
+			// 根据结果调整节奏，遇到限流时退避
+			delay := pacer.Next(err)
+			log.Info("等待下一次发布", "delay", delay)
+			time.Sleep(delay)
 		}
 	}
 
@@ -195,9 +440,181 @@ func main() {
 		"skipped", skipCount)
 }
 
-// findArticlesByDate 查找指定日期的文章
-func findArticlesByDate(sourcePath, dateStr string) ([]string, error) {
-	var articles []string
+// accountPipeline 单个公众号账号的发布组件。素材 media_id 不能跨账号使用，因此缓存也按账号区分
+type accountPipeline struct {
+	cfg    *config.Config
+	client *wechat.Client
+	media  *media.Manager
+	pub    *publisher.Publisher
+}
+
+// apiKeyEnv 直接读取 API 认证密钥的环境变量
+const apiKeyEnv = "AWP_API_KEY"
+
+// resolveAPIKey 按 -api-key 参数、AWP_API_KEY 环境变量、api.api_key 配置的顺序取认证密钥
+func resolveAPIKey(flagKey string, cfg *config.Config) string {
+	if flagKey != "" {
+		return flagKey
+	}
+	if key := os.Getenv(apiKeyEnv); key != "" {
+		return key
+	}
+	return cfg.API.APIKey
+}
+
+// checkAuthConfigured 启用 TLS 或监听非本机地址时必须配置认证密钥，
+// 避免环境变量未设置时服务在无认证的情况下对外开放
+func checkAuthConfigured(addr string, tls, authEnabled bool) error {
+	if authEnabled {
+		return nil
+	}
+	if tls {
+		return fmt.Errorf("TLS is configured but no API key is set (use -api-key, %s, api.api_key or api.keys)", apiKeyEnv)
+	}
+	if !isLoopbackAddr(addr) {
+		return fmt.Errorf("listening on %s without an API key (set -api-key, %s, api.api_key or api.keys, or listen on 127.0.0.1)", addr, apiKeyEnv)
+	}
+	return nil
+}
+
+// isLoopbackAddr 判断监听地址是否只在本机可访问 (主机为空表示监听所有地址)
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// openCache 打开账号对应的缓存文件
+func openCache(cfg *config.Config, account string) (*cache.Manager, error) {
+	storePath := cache.StorePath(cfg.Cache.StoreFile, account)
+	return cache.NewManager(storePath, cache.KeyStrategy(cfg.Cache.KeyStrategy))
+}
+
+// newAccountPipeline 使用账号凭据创建客户端、媒体管理器和发布器，cacheManager 为 nil 时打开该账号的缓存
+func newAccountPipeline(baseCfg *config.Config, account string, cacheManager *cache.Manager, log *logger.Logger) (*accountPipeline, error) {
+	cfg, err := baseCfg.ForAccount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheManager == nil {
+		if cacheManager, err = openCache(cfg, account); err != nil {
+			return nil, fmt.Errorf("open cache: %w", err)
+		}
+	}
+
+	wechatClient := wechat.NewClient(&cfg.WeChat, wechat.Timeouts{
+		Token:   time.Duration(cfg.Timeout.Token) * time.Second,
+		Upload:  time.Duration(cfg.Timeout.Upload) * time.Second,
+		Draft:   time.Duration(cfg.Timeout.Draft) * time.Second,
+		Default: time.Duration(cfg.Publish.Timeout) * time.Second,
+	}, cfg.Publish.MaxRetries)
+	wechatClient.SetLogger(log.Logger, cfg.Log.Redact)
+
+	mediaManager, err := media.NewManager(wechatClient, cacheManager, &cfg.Image)
+	if err != nil {
+		return nil, fmt.Errorf("create media manager: %w", err)
+	}
+
+	pub, err := publisher.NewPublisher(cfg, wechatClient, cacheManager, mediaManager, log)
+	if err != nil {
+		return nil, fmt.Errorf("create publisher: %w", err)
+	}
+	pub.SetDryRun(*dryRun, *dryRunDir)
+
+	return &accountPipeline{cfg: cfg, client: wechatClient, media: mediaManager, pub: pub}, nil
+}
+
+// accountRouter 按文章 front matter 中的账号选择发布组件，未指定时使用命令行所选账号
+type accountRouter struct {
+	baseCfg   *config.Config
+	account   string
+	pipelines map[string]*accountPipeline
+	log       *logger.Logger
+	mutex     sync.Mutex // 服务器模式下并发请求共用路由
+}
+
+// pipelineFor 返回文章所属账号的发布组件，首次使用的账号在此初始化
+func (r *accountRouter) pipelineFor(filePath string) (*accountPipeline, error) {
+	name := articleAccount(filePath)
+	if name == "" {
+		name = r.account
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if p, ok := r.pipelines[name]; ok {
+		return p, nil
+	}
+
+	p, err := newAccountPipeline(r.baseCfg, name, nil, r.log)
+	if err != nil {
+		return nil, fmt.Errorf("account %s: %w", name, err)
+	}
+	r.pipelines[name] = p
+	return p, nil
+}
+
+// publisherFor 返回文章所属账号的发布器，供 MCP / HTTP 服务器使用
+func (r *accountRouter) publisherFor(filePath string) (*publisher.Publisher, error) {
+	p, err := r.pipelineFor(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return p.pub, nil
+}
+
+// IsPublished 检查文章在其所属账号中是否已发布
+func (r *accountRouter) IsPublished(filePath string) (bool, error) {
+	p, err := r.pipelineFor(filePath)
+	if err != nil {
+		return false, err
+	}
+	return p.pub.IsPublished(filePath)
+}
+
+// PublishArticle 使用文章所属账号发布
+func (r *accountRouter) PublishArticle(ctx context.Context, filePath string) (*publisher.PublishResult, error) {
+	p, err := r.pipelineFor(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return p.pub.PublishArticle(ctx, filePath)
+}
+
+// articleAccount 读取文章 front matter 中指定的账号
+func articleAccount(filePath string) string {
+	article, err := markdown.NewParser().ParseFile(filePath)
+	if err != nil {
+		return ""
+	}
+	return article.Account
+}
+
+// articlePublishAt 读取文章 front matter 中的定时发布时间，未设置或无效时返回零值
+func articlePublishAt(filePath string) time.Time {
+	article, err := markdown.NewParser().ParseFile(filePath)
+	if err != nil || article.PublishAt == "" {
+		return time.Time{}
+	}
+	at, err := dates.ParseTimestamp(article.PublishAt, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return at
+}
+
+// findArticlesByDate 解析文章 front matter 中的 date 字段，返回日期范围 (按天，含两端) 内的文章，
+// 以 YYYY-MM-DD 分组。没有日期或日期无效的文章忽略
+func findArticlesByDate(sourcePath string, startDate, endDate time.Time) (map[string][]string, error) {
+	parser := markdown.NewParser()
+	articles := make(map[string][]string)
 
 	err := filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -209,19 +626,15 @@ func findArticlesByDate(sourcePath, dateStr string) ([]string, error) {
 			return nil
 		}
 
-		// 读取文件内容检查日期
-		content, err := os.ReadFile(path)
-		if err != nil {
+		article, err := parser.ParseFile(path)
+		if err != nil || article.DateTime.IsZero() {
 			return nil
 		}
 
-		// 简单检查是否包含日期
-		if strings.Contains(string(content), fmt.Sprintf("date: %s", dateStr)) ||
-			strings.Contains(string(content), fmt.Sprintf("date: '%s'", dateStr)) ||
-			strings.Contains(string(content), fmt.Sprintf("date: \"%s\"", dateStr)) {
-			articles = append(articles, path)
+		if dates.InDayRange(article.DateTime, startDate, endDate) {
+			day := article.DateTime.In(startDate.Location()).Format(dates.DateLayout)
+			articles[day] = append(articles[day], path)
 		}
-
 		return nil
 	})
 